@@ -0,0 +1,25 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/cmd/config/push"
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "config",
+		Short: "Manage ydbd configuration on the nodes",
+		Long: `ydbops config [command]:
+    Roll out ydbd configuration changes to the cluster nodes.`,
+		RunE: cli.RequireSubcommand,
+	})
+
+	cmd.AddCommand(
+		push.New(f),
+	)
+
+	return cmd
+}
@@ -0,0 +1,155 @@
+package push
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/rolling"
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+)
+
+const (
+	DefaultConfigMapKey        = "config.yaml"
+	DefaultReadyTimeoutSeconds = 300
+	readyPollInterval          = 5 * time.Second
+)
+
+type Options struct {
+	*rolling.RestartOptions
+
+	File         string
+	Path         string
+	ConfigMap    string
+	ConfigMapKey string
+	ReadyTimeout int
+
+	content []byte
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	o.RestartOptions.DefineFlags(fs)
+
+	fs.StringVar(&o.File, "file", "", "The new configuration file")
+
+	fs.StringVar(&o.Path, "path", restarters.DefaultConfigPath,
+		"Where the configuration file is located on the nodes")
+
+	fs.StringVar(&o.ConfigMap, "configmap", "",
+		"With --kubeconfig, the ConfigMap to put the configuration file into")
+
+	fs.StringVar(&o.ConfigMapKey, "configmap-key", DefaultConfigMapKey,
+		"With --kubeconfig, the key of the configuration file in the ConfigMap")
+
+	fs.IntVar(&o.ReadyTimeout, "ready-timeout", DefaultReadyTimeoutSeconds,
+		"How long to wait for a restarted node to come back up in CMS, in seconds. "+
+			"The previous configuration is restored and the rollout is aborted after that")
+}
+
+func (o *Options) Validate() error {
+	if err := o.RestartOptions.Validate(); err != nil {
+		return err
+	}
+
+	if o.File == "" {
+		return fmt.Errorf("--file unspecified, argument required")
+	}
+
+	content, err := os.ReadFile(o.File)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", o.File, err)
+	}
+
+	var parsed map[string]interface{}
+	if err = yaml.Unmarshal(content, &parsed); err != nil {
+		return fmt.Errorf("%s is not a valid yaml file: %w", o.File, err)
+	}
+	if len(parsed) == 0 {
+		return fmt.Errorf("%s is empty", o.File)
+	}
+	o.content = content
+
+	if o.KubeconfigPath != "" && o.ConfigMap == "" {
+		return fmt.Errorf("--configmap unspecified, argument required with --kubeconfig")
+	}
+
+	if o.ReadyTimeout <= 0 {
+		return fmt.Errorf("specified invalid ready timeout: %d. Must be positive", o.ReadyTimeout)
+	}
+
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	if err := o.ApplyPlan(); err != nil {
+		return err
+	}
+
+	storageRestarter, tenantRestarter := restarters.PrepareRestarters(
+		&o.TargetingOptions,
+		o.SSHArgs,
+		o.CustomSystemdUnitName,
+		o.TenantSystemdUnitName,
+		o.RestartDuration,
+	)
+
+	configOpts := restarters.ConfigOpts{
+		Content:           o.content,
+		ReadyTimeout:      time.Duration(o.ReadyTimeout) * time.Second,
+		ReadyPollInterval: readyPollInterval,
+		Ready:             nodeReadyChecker(f.GetCMSClient()),
+	}
+
+	if o.KubeconfigPath != "" {
+		configMap := restarters.NewConfigMap(options.Logger, o.KubeconfigPath, o.K8sNamespace, o.ConfigMap, o.ConfigMapKey)
+		k8sOpts := &restarters.ConfigK8sOpts{ConfigOpts: configOpts, ConfigMap: configMap}
+		return rolling.RunRestarters(
+			f,
+			o.RestartOptions,
+			restarters.NewConfigK8sRestarter(options.Logger, storageRestarter, k8sOpts),
+			restarters.NewConfigK8sRestarter(options.Logger, tenantRestarter, k8sOpts),
+		)
+	}
+
+	sshOpts := func() *restarters.ConfigSSHOpts {
+		return &restarters.ConfigSSHOpts{ConfigOpts: configOpts, Path: o.Path}
+	}
+	return rolling.RunRestarters(
+		f,
+		o.RestartOptions,
+		restarters.NewConfigSSHRestarter(options.Logger, o.SSHArgs, storageRestarter, sshOpts()),
+		restarters.NewConfigSSHRestarter(options.Logger, o.SSHArgs, tenantRestarter, sshOpts()),
+	)
+}
+
+// nodeReadyChecker tells if a node is up in CMS and has been started
+// since it was listed before the restart.
+func nodeReadyChecker(cmsClient cms.Client) func(*Ydb_Maintenance.Node) error {
+	return func(before *Ydb_Maintenance.Node) error {
+		nodes, err := cmsClient.Nodes()
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			if node.GetNodeId() != before.GetNodeId() {
+				continue
+			}
+			if node.GetState() != Ydb_Maintenance.ItemState_ITEM_STATE_UP {
+				return fmt.Errorf("node %d is in state %s", node.GetNodeId(), node.GetState())
+			}
+			if before.GetStartTime() != nil && !node.GetStartTime().AsTime().After(before.GetStartTime().AsTime()) {
+				return fmt.Errorf("node %d has not been restarted yet", node.GetNodeId())
+			}
+			return nil
+		}
+		return fmt.Errorf("node %d is not listed by CMS", before.GetNodeId())
+	}
+}
@@ -0,0 +1,45 @@
+package push
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/rolling"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{
+		RestartOptions: &rolling.RestartOptions{},
+	}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "push",
+		Short: "Roll out a new config.yaml to the nodes",
+		Long: `ydbops config push:
+  Replaces the configuration file on the nodes and restarts them, one
+  CMS-approved node at a time, the same way as 'ydbops restart' does.
+
+  Over ssh, the current file is fetched from every node and the changes are
+  shown (once for a run of nodes with identical changes). The file is backed up
+  to '<path>.previous' and replaced atomically.
+  With --kubeconfig, the ConfigMap specified with --configmap is updated instead.
+
+  If a node does not come back up in CMS after the restart, the previous
+  configuration is restored, the node is restarted again and the rest of the
+  rollout is aborted.`,
+		PreRunE: cli.PopulateProfileDefaultsAndValidate(f.GetBaseOptions(), opts),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("free args not expected: %v", args)
+			}
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,49 @@
+package exec
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/command"
+)
+
+var ExecCommandDescription = command.NewDescription(
+	"exec",
+	"Run a shell command on a specified subset of hosts in the cluster",
+	`ydbops exec:
+  Run a shell command or script on every host selected by the usual filters,
+  over the same ssh transport 'ydbops restart' uses. A host running several
+  nodes is visited once.
+
+  Unlike 'ydbops run', exec does not create maintenance tasks or wait for CMS,
+  so it must only be used for commands that are safe to run on all selected
+  hosts at once, e.g. collecting diagnostics.
+
+  The script is fed into 'bash -s' on the host. Stdout, stderr and the exit
+  code of every host are printed when all hosts finish, see --output for
+  the json and yaml formats. ydbops fails if the command fails on any host.`,
+)
+
+func New(
+	f cmdutil.Factory,
+) *cobra.Command {
+	opts := &Options{}
+	cmd := &cobra.Command{
+		Use:     ExecCommandDescription.GetUse(),
+		Short:   ExecCommandDescription.GetShortDescription(),
+		Long:    ExecCommandDescription.GetLongDescription(),
+		PreRunE: cli.PopulateProfileDefaultsAndValidate(f.GetBaseOptions(), opts),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("free args not expected: %v", args)
+			}
+			return opts.Run(f)
+		},
+	}
+
+	opts.DefineFlags(cmd.Flags())
+	return cmd
+}
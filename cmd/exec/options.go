@@ -0,0 +1,266 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/prettyprint"
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+	"github.com/ydb-platform/ydbops/pkg/utils"
+)
+
+const (
+	DefaultParallel = 10
+)
+
+var rawSSHUnparsedArgs string
+
+type Options struct {
+	options.TargetingOptions
+
+	PayloadFilePath string
+	Command         string
+	Parallel        int
+	HostTimeout     time.Duration
+	SSHArgs         []string
+}
+
+// HostResult is the machine-readable schema of the outcome on a single host,
+// printed with --output json|yaml.
+type HostResult struct {
+	Host     string   `json:"host" yaml:"host"`
+	NodeIDs  []uint32 `json:"nodeIds" yaml:"nodeIds"`
+	ExitCode int      `json:"exitCode" yaml:"exitCode"`
+	Stdout   string   `json:"stdout" yaml:"stdout"`
+	Stderr   string   `json:"stderr" yaml:"stderr"`
+	Error    string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type execResult struct {
+	Hosts []HostResult `json:"hosts" yaml:"hosts"`
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	o.TargetingOptions.DefineFlags(fs)
+
+	fs.StringVar(&o.PayloadFilePath, "payload", "",
+		"File path to a shell script to run on every selected host")
+
+	fs.StringVar(&o.Command, "command", "",
+		"Shell command to run on every selected host, instead of --payload")
+
+	fs.IntVar(&o.Parallel, "parallel", DefaultParallel,
+		"How many hosts to run the command on at the same time")
+
+	fs.DurationVar(&o.HostTimeout, "host-timeout", 0,
+		`Give up on a host where the command takes longer than this, e.g. '5m'. ssh is killed
+  and the host is reported as failed. Zero means no timeout`)
+
+	fs.StringVar(&rawSSHUnparsedArgs, "ssh-args", "",
+		`This argument will be used when ssh-ing to the nodes. It may be used to override
+the ssh command itself, ssh username or any additional arguments, same as for restart`)
+}
+
+func (o *Options) Validate() error {
+	if err := o.TargetingOptions.Validate(); err != nil {
+		return err
+	}
+
+	if o.KubeconfigPath != "" {
+		return fmt.Errorf("exec runs commands over ssh and does not support --kubeconfig")
+	}
+
+	if o.PayloadFilePath == "" && o.Command == "" {
+		return fmt.Errorf("--payload or --command unspecified, argument required")
+	}
+
+	if o.PayloadFilePath != "" && o.Command != "" {
+		return fmt.Errorf("--payload and --command can not be specified together")
+	}
+
+	if o.PayloadFilePath != "" {
+		if _, err := os.Stat(o.PayloadFilePath); errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("payload file '%s' does not exist", o.PayloadFilePath)
+		}
+	}
+
+	if o.Parallel <= 0 {
+		return fmt.Errorf("specified invalid parallel: %d. Must be positive", o.Parallel)
+	}
+
+	if o.HostTimeout < 0 {
+		return fmt.Errorf("specified invalid host timeout: %s. Must not be negative", o.HostTimeout)
+	}
+
+	o.SSHArgs = utils.ParseSSHArgs(rawSSHUnparsedArgs)
+
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	script := []byte(o.Command)
+	if o.PayloadFilePath != "" {
+		content, err := os.ReadFile(o.PayloadFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read payload file %s: %w", o.PayloadFilePath, err)
+		}
+		script = content
+	}
+
+	nodes, err := f.GetCMSClient().Nodes()
+	if err != nil {
+		return err
+	}
+
+	targetedNodes, err := restarters.SelectNodes(&o.TargetingOptions, nodes)
+	if err != nil {
+		return err
+	}
+
+	hosts, nodeIDs := groupByHost(targetedNodes)
+	if len(hosts) == 0 {
+		options.Logger.Info("No hosts matched the filters, nothing to do")
+		return nil
+	}
+
+	options.Logger.Infof("Running on %d hosts, %d at a time", len(hosts), o.Parallel)
+
+	executor := restarters.NewSSHExecutor(options.Logger, o.SSHArgs)
+	results := make([]HostResult, len(hosts))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, o.Parallel)
+	for i, host := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = o.runOnHost(executor, host, nodeIDs[host], script)
+			if results[i].Error != "" {
+				options.Logger.Warnf("Failed on %s: %s", host, results[i].Error)
+			} else {
+				options.Logger.Infof("Finished on %s with exit code %d", host, results[i].ExitCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if format := f.GetBaseOptions().Output; format != options.OutputText {
+		content, err := prettyprint.Marshal(format, execResult{Hosts: results})
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+	} else {
+		fmt.Print(resultsToString(results))
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.ExitCode != 0 {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("command failed on %d out of %d hosts", failed, len(results))
+	}
+	return nil
+}
+
+func (o *Options) runOnHost(executor *restarters.SSHExecutor, host string, nodeIDs []uint32, script []byte) HostResult {
+	ctx := context.Background()
+	if o.HostTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, o.HostTimeout,
+			fmt.Errorf("the command did not finish in %s", o.HostTimeout))
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := executor.Run(ctx, host, script, &stdout, &stderr)
+
+	result := HostResult{
+		Host:     host,
+		NodeIDs:  nodeIDs,
+		ExitCode: exitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// groupByHost returns the hosts of the nodes in the order they were first met,
+// a host running several nodes is only visited once.
+func groupByHost(nodes []*Ydb_Maintenance.Node) ([]string, map[string][]uint32) {
+	hosts := []string{}
+	nodeIDs := make(map[string][]uint32)
+	for _, node := range nodes {
+		if _, present := nodeIDs[node.GetHost()]; !present {
+			hosts = append(hosts, node.GetHost())
+		}
+		nodeIDs[node.GetHost()] = append(nodeIDs[node.GetHost()], node.GetNodeId())
+	}
+	return hosts, nodeIDs
+}
+
+func resultsToString(results []HostResult) string {
+	sb := strings.Builder{}
+
+	for _, result := range results {
+		if result.Error != "" {
+			sb.WriteString(fmt.Sprintf("=== %s (error)\n", result.Host))
+			sb.WriteString(fmt.Sprintf("error: %s\n", result.Error))
+		} else {
+			sb.WriteString(fmt.Sprintf("=== %s (exit code %d)\n", result.Host, result.ExitCode))
+		}
+		writeStream(&sb, "stdout", result.Stdout)
+		writeStream(&sb, "stderr", result.Stderr)
+		sb.WriteString("\n")
+	}
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tNODE IDS\tEXIT CODE")
+	for _, result := range results {
+		ids := make([]string, 0, len(result.NodeIDs))
+		for _, id := range result.NodeIDs {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		exitCode := fmt.Sprint(result.ExitCode)
+		if result.Error != "" {
+			exitCode = result.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Host, strings.Join(ids, ","), exitCode)
+	}
+	_ = w.Flush()
+
+	return sb.String()
+}
+
+func writeStream(sb *strings.Builder, name, content string) {
+	if content == "" {
+		return
+	}
+	sb.WriteString("--- " + name + "\n")
+	sb.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		sb.WriteString("\n")
+	}
+}
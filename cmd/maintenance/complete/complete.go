@@ -15,7 +15,10 @@ func New(f cmdutil.Factory) *cobra.Command {
 		Short: "Declare the maintenance task completed",
 		Long: `ydbops maintenance complete:
   Any hosts that have been given to you within the task will be considered returned to the cluster.
-  You must not perform any host maintenance after you called this command.`,
+  You must not perform any host maintenance after you called this command.
+
+  Use --all to complete every PERFORMED action of the task at once, or --performed-only
+  to skip hosts from --hosts that have not been given to you yet.`,
 		PreRunE: cli.PopulateProfileDefaultsAndValidate(
 			f.GetBaseOptions(), opts,
 		),
@@ -2,16 +2,25 @@ package complete
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/pflag"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
 	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/options"
 	"github.com/ydb-platform/ydbops/pkg/prettyprint"
 )
 
 type Options struct {
-	TaskID string
-	Hosts  []string
+	TaskID        string
+	Hosts         []string
+	PDisks        []string
+	All           bool
+	PerformedOnly bool
+	Yes           bool
 }
 
 func (o *Options) DefineFlags(fs *pflag.FlagSet) {
@@ -21,25 +30,147 @@ func (o *Options) DefineFlags(fs *pflag.FlagSet) {
   E.g.: '--hosts=1,2,3' or '--hosts=fqdn1,fqdn2,fqdn3'`)
 	fs.StringVar(&o.TaskID, "task-id", "",
 		"ID of your maintenance task (result of `ydbops maintenance host`)")
+	fs.StringSliceVar(&o.PDisks, "pdisks", []string{},
+		"Pdisks with completed maintenance, in the same form they were requested in `ydbops maintenance create --pdisks`")
+	fs.BoolVar(&o.All, "all", false,
+		"Complete every PERFORMED action of the task instead of listing --hosts")
+	fs.BoolVar(&o.PerformedOnly, "performed-only", false,
+		"Skip hosts from --hosts or --pdisks whose actions are not PERFORMED yet")
+	fs.BoolVar(&o.Yes, "yes", false,
+		"Do not ask for confirmation when completing actions with --all")
 }
 
 func (o *Options) Validate() error {
 	// TODO(shmel1k@): remove copypaste between drop, create & refresh methods.
-	if len(o.Hosts) == 0 {
-		return fmt.Errorf("--hosts unspecified")
-	}
 	if o.TaskID == "" {
 		return fmt.Errorf("--task-id unspecified, argument required")
 	}
+	specified := 0
+	for _, set := range []bool{o.All, len(o.Hosts) > 0, len(o.PDisks) > 0} {
+		if set {
+			specified++
+		}
+	}
+	if specified == 0 {
+		return fmt.Errorf("one of --hosts, --pdisks and --all must be specified")
+	}
+	if specified > 1 {
+		return fmt.Errorf("only one of --all, --hosts and --pdisks can be specified")
+	}
+	if _, err := cms.ParsePDisks(o.PDisks); err != nil {
+		return err
+	}
+	if o.All && o.PerformedOnly {
+		return fmt.Errorf("--performed-only is implied by --all, specify only one of them")
+	}
 	return nil
 }
 
 func (o *Options) Run(f cmdutil.Factory) error {
-	result, err := f.GetCMSClient().CompleteActions(o.TaskID, o.Hosts)
+	var (
+		result *Ydb_Maintenance.ManageActionResult
+		err    error
+	)
+
+	if o.All || o.PerformedOnly || len(o.PDisks) > 0 {
+		result, err = o.completeSelected(f)
+	} else {
+		result, err = f.GetCMSClient().CompleteActions(o.TaskID, o.Hosts)
+	}
 	if err != nil {
 		return err
 	}
 
+	if output := f.GetBaseOptions().Output; output != options.OutputText {
+		content, err := prettyprint.Marshal(output, prettyprint.ResultToStructured(result))
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+		return nil
+	}
+
 	fmt.Println(prettyprint.ResultToString(result))
 	return nil
 }
+
+// completeSelected completes actions of the task on --hosts or --pdisks, or
+// all of them with --all. With --all or --performed-only, actions that are not
+// PERFORMED are skipped, which is reported to stderr to keep --output parseable. Returns an empty result if there is nothing to complete.
+func (o *Options) completeSelected(f cmdutil.Factory) (*Ydb_Maintenance.ManageActionResult, error) {
+	task, err := f.GetCMSClient().GetMaintenanceTask(o.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance task %v: %w", o.TaskID, err)
+	}
+
+	selected, err := o.selectActions(task)
+	if err != nil {
+		return nil, err
+	}
+
+	performed := []*Ydb_Maintenance.ActionUid{}
+	for _, as := range selected {
+		if !(o.All || o.PerformedOnly) || as.GetStatus() == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
+			performed = append(performed, as.GetActionUid())
+		} else {
+			fmt.Fprintf(os.Stderr, "Skipping action that is not PERFORMED: %s\n", prettyprint.ActionStateToString(as))
+		}
+	}
+
+	if len(performed) == 0 {
+		fmt.Fprintf(os.Stderr, "There are no PERFORMED actions to complete in task %s\n", o.TaskID)
+		return &Ydb_Maintenance.ManageActionResult{}, nil
+	}
+
+	if o.All {
+		confirmed, err := cli.Confirm(
+			fmt.Sprintf("Complete %d PERFORMED actions of task %s?", len(performed), o.TaskID),
+			o.Yes,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !confirmed {
+			return nil, fmt.Errorf("aborted by user")
+		}
+	}
+
+	return f.GetCMSClient().CompleteAction(performed)
+}
+
+func (o *Options) selectActions(task cms.MaintenanceTask) ([]*Ydb_Maintenance.ActionState, error) {
+	lockActions := cms.LockActions(task)
+	if o.All {
+		return lockActions, nil
+	}
+
+	if len(o.PDisks) > 0 {
+		pdisks, err := cms.ParsePDisks(o.PDisks)
+		if err != nil {
+			return nil, err
+		}
+		specs := make([]string, 0, len(pdisks))
+		for _, pdisk := range pdisks {
+			specs = append(specs, pdisk.String())
+		}
+		selected, missing := cms.SelectByScope(lockActions, specs, func(scope *Ydb_Maintenance.ActionScope) string {
+			pdisk, _ := cms.PDiskFromScope(scope)
+			return pdisk.String()
+		})
+		return selected, notFound(missing)
+	}
+
+	selected, missing, err := cms.SelectByHosts(lockActions, o.Hosts)
+	if err != nil {
+		return nil, err
+	}
+	return selected, notFound(missing)
+}
+
+func notFound(missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to complete host %s, corresponding CMS action not found.\n"+
+		"This host either was never requested or already completed", missing[0])
+}
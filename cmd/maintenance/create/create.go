@@ -14,7 +14,8 @@ func New(f cmdutil.Factory) *cobra.Command {
 		Use:   "create",
 		Short: "Create a maintenance task to obtain a set of hosts",
 		Long: `ydbops maintenance create:
-  Create a maintenance task, which allows taking the set of hosts out of the cluster.`,
+  Create a maintenance task, which allows taking the set of hosts out of the cluster.
+  With --pdisks, only the specified physical disks are locked instead of whole hosts.`,
 		PreRunE: cli.PopulateProfileDefaultsAndValidate(
 			f.GetBaseOptions(), opts,
 		),
@@ -10,7 +10,6 @@ import (
 
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 
-	"github.com/ydb-platform/ydbops/cmd/restart"
 	"github.com/ydb-platform/ydbops/pkg/client/cms"
 	"github.com/ydb-platform/ydbops/pkg/cmdutil"
 	"github.com/ydb-platform/ydbops/pkg/options"
@@ -21,8 +20,10 @@ import (
 
 type Options struct {
 	options.TargetingOptions
+	options.GroupingOptions
 
 	MaintenanceDuration int
+	PDisks              []string
 }
 
 const (
@@ -31,10 +32,16 @@ const (
 
 func (o *Options) DefineFlags(fs *pflag.FlagSet) {
 	o.TargetingOptions.DefineFlags(fs)
+	o.GroupingOptions.DefineFlags(fs)
 
 	fs.IntVar(&o.MaintenanceDuration, "duration", DefaultMaintenanceDurationSeconds,
 		`CMS will release the node for maintenance for duration seconds. Any maintenance
 after that would be considered a regular cluster failure`)
+
+	fs.StringSliceVar(&o.PDisks, "pdisks", []string{},
+		`Lock single physical disks instead of whole hosts. You can specify pdisks either as
+  <node-id>:<pdisk-id> or as <host-fqdn>:<device-path>. The list is comma-delimited.
+  E.g.: '--pdisks=1:1000,2:1001' or '--pdisks=fqdn1:/dev/disk/by-partlabel/kikimr_nvme_01'`)
 }
 
 func (o *Options) Validate() error {
@@ -42,6 +49,22 @@ func (o *Options) Validate() error {
 		return fmt.Errorf("specified invalid maintenance duration: %d. Must be positive", o.MaintenanceDuration)
 	}
 
+	if len(o.PDisks) > 0 {
+		if len(o.Hosts) > 0 {
+			return fmt.Errorf("--pdisks and --hosts can not be specified together")
+		}
+		if _, err := cms.ParsePDisks(o.PDisks); err != nil {
+			return err
+		}
+		if o.GroupBy != options.GroupByNone {
+			return fmt.Errorf("--group-by can not be used with --pdisks")
+		}
+	}
+
+	if err := o.GroupingOptions.Validate(); err != nil {
+		return err
+	}
+
 	return o.TargetingOptions.Validate()
 }
 
@@ -52,15 +75,16 @@ func (o *Options) nodeIdsToNodes(
 	targetedNodes := make([]*Ydb_Maintenance.Node, 0, len(nodes))
 
 	// TODO @jorres arguments to PrepareRestarters are a dirty hack.
-	// We actually only need Filter component from restarters. 2 and 3 arguments
+	// We actually only need Filter component from restarters. 2, 3 and 4 arguments
 	// are required in PrepareRestarters to actually perform node restarts,
 	// but we only use restarters in the scope of this function to filter nodes
 	// so their value does not matter. Splitting something like 'Filterers' from
 	// Restarters into separate interface should solve this.
-	storageRestarter, tenantRestarter := restart.PrepareRestarters(
+	storageRestarter, tenantRestarter := restarters.PrepareRestarters(
 		&o.TargetingOptions,
 		[]string{},
 		"",
+		"",
 		o.MaintenanceDuration,
 	)
 
@@ -90,51 +114,80 @@ func (o *Options) Run(f cmdutil.Factory) error {
 	taskUID := cms.TaskUuidPrefix + uuid.New().String()
 	duration := time.Duration(o.MaintenanceDuration) * time.Second
 
-	nodes, err := f.GetCMSClient().Nodes()
+	task, err := o.createTask(f, taskUID, duration)
 	if err != nil {
 		return err
 	}
+
+	if output := f.GetBaseOptions().Output; output != options.OutputText {
+		content, err := prettyprint.Marshal(output, prettyprint.TaskToStructured(task))
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+		return nil
+	}
+
+	fmt.Printf(
+		"Your task id is:\n\n%s\n\nPlease write it down for refreshing and completing the task later.\n",
+		task.GetTaskUid(),
+	)
+
+	fmt.Println(prettyprint.TaskToString(task))
+
+	return nil
+}
+
+func (o *Options) createTask(f cmdutil.Factory, taskUID string, duration time.Duration) (cms.MaintenanceTask, error) {
+	if len(o.PDisks) > 0 {
+		pdisks, err := cms.ParsePDisks(o.PDisks)
+		if err != nil {
+			return nil, err
+		}
+		return f.GetCMSClient().CreateMaintenanceTask(cms.MaintenanceTaskParams{
+			PDisks:           pdisks,
+			Duration:         durationpb.New(duration),
+			AvailabilityMode: o.GetAvailabilityMode(),
+			Priority:         int32(o.Priority),
+			ScopeType:        cms.PDiskScope,
+			TaskUID:          taskUID,
+		})
+	}
+
+	nodes, err := f.GetCMSClient().Nodes()
+	if err != nil {
+		return nil, err
+	}
 	nodeIds, errIds := utils.GetNodeIds(o.Hosts)
 	hostFQDNs, errFqdns := utils.GetNodeFQDNs(o.Hosts)
 	if errIds != nil && errFqdns != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"failed to parse --hosts argument as node ids (%w) or host fqdns (%w)",
 			errIds,
 			errFqdns,
 		)
 	}
 
-	var task cms.MaintenanceTask
 	if errIds == nil {
-		task, err = f.GetCMSClient().CreateMaintenanceTask(cms.MaintenanceTaskParams{
+		return f.GetCMSClient().CreateMaintenanceTask(cms.MaintenanceTaskParams{
 			Nodes:            o.nodeIdsToNodes(nodes, nodeIds),
 			Duration:         durationpb.New(duration),
 			AvailabilityMode: o.GetAvailabilityMode(),
 			Priority:         int32(o.Priority),
 			ScopeType:        cms.NodeScope,
 			TaskUID:          taskUID,
-		})
-	} else {
-		task, err = f.GetCMSClient().CreateMaintenanceTask(cms.MaintenanceTaskParams{
-			Hosts:            hostFQDNs,
-			Duration:         durationpb.New(duration),
-			AvailabilityMode: o.GetAvailabilityMode(),
-			Priority:         int32(o.Priority),
-			ScopeType:        cms.HostScope,
-			TaskUID:          taskUID,
+			GroupKey:         o.NodeGroupKey(),
 		})
 	}
-
-	if err != nil {
-		return err
+	if o.GroupBy != options.GroupByNone {
+		return nil, fmt.Errorf("--group-by requires node ids in --hosts, host FQDNs are locked as a whole anyway")
 	}
-
-	fmt.Printf(
-		"Your task id is:\n\n%s\n\nPlease write it down for refreshing and completing the task later.\n",
-		task.GetTaskUid(),
-	)
-
-	fmt.Println(prettyprint.TaskToString(task))
-
-	return nil
+	return f.GetCMSClient().CreateMaintenanceTask(cms.MaintenanceTaskParams{
+		Hosts:            hostFQDNs,
+		Duration:         durationpb.New(duration),
+		AvailabilityMode: o.GetAvailabilityMode(),
+		Priority:         int32(o.Priority),
+		ScopeType:        cms.HostScope,
+		TaskUID:          taskUID,
+	})
 }
@@ -12,11 +12,14 @@ func New(f cmdutil.Factory) *cobra.Command {
 
 	cmd := cli.SetDefaultsOn(&cobra.Command{
 		Use:   "drop",
-		Short: "Drop an existing maintenance task",
+		Short: "Drop existing maintenance tasks",
 		Long: `ydbops maintenance drop:
   Drops the maintenance task, meaning two things:
   1. Any hosts given within the maintenance task will be considered returned.
-  2. Any hosts requested, but not yet given, will not be reserved for you any longer.`,
+  2. Any hosts requested, but not yet given, will not be reserved for you any longer.
+
+  Several tasks can be dropped at once: pass a list to --task-id or use --all-mine,
+  optionally narrowed with --prefix and --min-age. Bulk drops ask for confirmation unless --yes is given.`,
 		PreRunE: cli.PopulateProfileDefaultsAndValidate(
 			f.GetBaseOptions(), taskIdOpts,
 		),
@@ -2,28 +2,157 @@ package drop
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
 	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/prettyprint"
 )
 
 type Options struct {
-	TaskID string
+	TaskIDs []string
+	AllMine bool
+	Prefix  string
+	MinAge  int
+	Yes     bool
 }
 
 func (o *Options) DefineFlags(fs *pflag.FlagSet) {
-	fs.StringVar(&o.TaskID, "task-id", "",
-		"ID of your maintenance task (result of `ydbops maintenance host`)")
+	fs.StringSliceVar(&o.TaskIDs, "task-id", []string{},
+		"IDs of your maintenance tasks (result of `ydbops maintenance host`). The list is comma-delimited")
+	fs.BoolVar(&o.AllMine, "all-mine", false,
+		"Drop all maintenance tasks of the current user")
+	fs.StringVar(&o.Prefix, "prefix", "",
+		"Drop only tasks whose id starts with this prefix, e.g. 'rolling-restart-'")
+	fs.IntVar(&o.MinAge, "min-age", 0,
+		"Drop only tasks created at least this many seconds ago")
+	fs.BoolVar(&o.Yes, "yes", false,
+		"Do not ask for confirmation when dropping more than one task")
 }
 
 func (o *Options) Validate() error {
-	if o.TaskID == "" {
+	if len(o.TaskIDs) == 0 && !o.AllMine {
 		return fmt.Errorf("--task-id unspecified, argument required")
 	}
+	if len(o.TaskIDs) > 0 && o.AllMine {
+		return fmt.Errorf("--task-id and --all-mine can not be specified together")
+	}
+	if o.MinAge < 0 {
+		return fmt.Errorf("specified invalid --min-age: %d. Must be positive", o.MinAge)
+	}
 	return nil
 }
 
 func (o *Options) Run(f cmdutil.Factory) error {
-	return f.GetCMSClient().DropTask(o.TaskID)
+	taskIDs, err := o.selectTasks(f)
+	if err != nil {
+		return err
+	}
+
+	structured := f.GetBaseOptions().Output != options.OutputText
+
+	if len(taskIDs) == 0 {
+		if !structured {
+			fmt.Println("There are no maintenance tasks to drop.")
+		}
+		return o.printDropped(f, taskIDs)
+	}
+
+	bulk := len(taskIDs) > 1 || o.AllMine
+	if bulk {
+		confirmed, err := cli.Confirm(
+			fmt.Sprintf("Drop %d maintenance tasks: %s?", len(taskIDs), strings.Join(taskIDs, ", ")),
+			o.Yes,
+		)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("aborted by user")
+		}
+	}
+
+	dropped := make([]string, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		if err := f.GetCMSClient().DropTask(taskID); err != nil {
+			return fmt.Errorf("failed to drop maintenance task %s (dropped %d of %d): %w",
+				taskID, len(dropped), len(taskIDs), err)
+		}
+		dropped = append(dropped, taskID)
+		if !structured && bulk {
+			fmt.Printf("Dropped maintenance task %s\n", taskID)
+		}
+	}
+
+	return o.printDropped(f, dropped)
+}
+
+func (o *Options) printDropped(f cmdutil.Factory, dropped []string) error {
+	output := f.GetBaseOptions().Output
+	if output == options.OutputText {
+		return nil
+	}
+
+	content, err := prettyprint.Marshal(output, prettyprint.DropResult{DroppedTaskUIDs: dropped})
+	if err != nil {
+		return err
+	}
+	fmt.Print(content)
+	return nil
+}
+
+// selectTasks resolves --task-id or --all-mine into task ids and applies
+// --prefix and --min-age filters.
+func (o *Options) selectTasks(f cmdutil.Factory) ([]string, error) {
+	var tasks []cms.MaintenanceTask
+
+	switch {
+	case o.AllMine:
+		userSID, err := f.GetDiscoveryClient().WhoAmI()
+		if err != nil {
+			return nil, err
+		}
+		tasks, err = f.GetCMSClient().MaintenanceTasks(userSID)
+		if err != nil {
+			return nil, err
+		}
+	case o.MinAge > 0:
+		// Creation time is only known from the task itself.
+		for _, taskID := range o.TaskIDs {
+			task, err := f.GetCMSClient().GetMaintenanceTask(taskID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get maintenance task %v: %w", taskID, err)
+			}
+			tasks = append(tasks, task)
+		}
+	default:
+		result := []string{}
+		for _, taskID := range o.TaskIDs {
+			if strings.HasPrefix(taskID, o.Prefix) {
+				result = append(result, taskID)
+			}
+		}
+		return result, nil
+	}
+
+	now := time.Now()
+	result := []string{}
+	for _, task := range tasks {
+		if !strings.HasPrefix(task.GetTaskUid(), o.Prefix) {
+			continue
+		}
+		if o.MinAge > 0 {
+			createTime, ok := cms.TaskCreateTime(task)
+			if !ok || now.Sub(createTime) < time.Duration(o.MinAge)*time.Second {
+				continue
+			}
+		}
+		result = append(result, task.GetTaskUid())
+	}
+	return result, nil
 }
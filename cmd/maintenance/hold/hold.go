@@ -0,0 +1,34 @@
+package hold
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "hold",
+		Short: "Keep the locks of a maintenance task from expiring",
+		Long: `ydbops maintenance hold:
+  Periodically refreshes the maintenance task and extends its locks before
+  their deadlines. CMS can not prolong an existing lock, so the locks are
+  requested again in a replacement task and the original task is dropped,
+  passing the locks on to the replacement. The task id changes every time:
+  the new id is printed and kept in --task-id-file.
+  Runs until interrupted, then optionally completes or drops the task.`,
+		PreRunE: cli.PopulateProfileDefaultsAndValidate(
+			f.GetBaseOptions(), opts,
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.PersistentFlags())
+
+	return cmd
+}
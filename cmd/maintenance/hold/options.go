@@ -0,0 +1,231 @@
+package hold
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/internal/collections"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/lease"
+	"github.com/ydb-platform/ydbops/pkg/options"
+)
+
+const (
+	DefaultLockDurationSeconds     = 3600
+	DefaultRenewBeforeSeconds      = 300
+	DefaultCMSQueryIntervalSeconds = 10
+
+	OnExitNone     = "none"
+	OnExitComplete = "complete"
+	OnExitDrop     = "drop"
+)
+
+var onExitActions = []string{OnExitNone, OnExitComplete, OnExitDrop}
+
+type Options struct {
+	TaskID           string
+	AvailabilityMode string
+	Duration         int
+	RenewBefore      int
+	CMSQueryInterval int
+	OnExit           string
+
+	TaskIDFile string
+
+	Daemon  bool
+	LogFile string
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.TaskID, "task-id", "",
+		"ID of your maintenance task (result of `ydbops maintenance host`)")
+
+	fs.StringVar(&o.AvailabilityMode, "availability-mode", "strong",
+		fmt.Sprintf(`Availability mode used to request extended locks, should match the one the task was created with.
+Available choices: %s`, strings.Join(options.AvailabilityModes, ", ")))
+
+	fs.IntVar(&o.Duration, "duration", DefaultLockDurationSeconds,
+		"Duration in seconds of every extended lock")
+
+	fs.IntVar(&o.RenewBefore, "renew-before", DefaultRenewBeforeSeconds,
+		"Extend a lock when it has less than this many seconds left before its deadline")
+
+	fs.IntVar(&o.CMSQueryInterval, "cms-query-interval", DefaultCMSQueryIntervalSeconds,
+		fmt.Sprintf("How often to refresh the task in CMS %v", DefaultCMSQueryIntervalSeconds))
+
+	fs.StringVar(&o.OnExit, "on-exit", OnExitNone,
+		fmt.Sprintf(`What to do with the task when interrupted by SIGINT or SIGTERM.
+Available choices: %s`, strings.Join(onExitActions, ", ")))
+
+	fs.StringVar(&o.TaskIDFile, "task-id-file", "",
+		`Keep the id of the task holding the locks in this file. The id changes every time the locks
+are extended, the new id is also printed. Required with --daemon`)
+
+	fs.BoolVar(&o.Daemon, "daemon", false,
+		"Detach from the terminal and keep the locks alive in background, see --log-file")
+
+	fs.StringVar(&o.LogFile, "log-file", "",
+		"Where the background lease keeper writes its logs. Required with --daemon")
+}
+
+func (o *Options) Validate() error {
+	if o.TaskID == "" {
+		return fmt.Errorf("--task-id unspecified, argument required")
+	}
+	if !collections.Contains(options.AvailabilityModes, o.AvailabilityMode) {
+		return fmt.Errorf("specified a non-existing availability mode: %s", o.AvailabilityMode)
+	}
+	if o.CMSQueryInterval <= 0 {
+		return fmt.Errorf("specified invalid cms query interval seconds: %d. Must be positive", o.CMSQueryInterval)
+	}
+	if o.RenewBefore <= o.CMSQueryInterval {
+		return fmt.Errorf(
+			"specified invalid --renew-before: %d. Must be greater than --cms-query-interval (%d)",
+			o.RenewBefore, o.CMSQueryInterval,
+		)
+	}
+	if o.Duration <= o.RenewBefore {
+		return fmt.Errorf(
+			"specified invalid --duration: %d. Must be greater than --renew-before (%d)",
+			o.Duration, o.RenewBefore,
+		)
+	}
+	if !collections.Contains(onExitActions, o.OnExit) {
+		return fmt.Errorf("specified a non-existing --on-exit action: %s", o.OnExit)
+	}
+	if o.Daemon && o.LogFile == "" {
+		return fmt.Errorf("--log-file unspecified, argument required with --daemon")
+	}
+	if o.Daemon && o.TaskIDFile == "" {
+		return fmt.Errorf("--task-id-file unspecified, argument required with --daemon")
+	}
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	if o.Daemon {
+		return startDaemon(o.LogFile, o.TaskIDFile, o.TaskID)
+	}
+
+	logger := zap.S()
+	keeper := lease.NewKeeper(f.GetCMSClient(), logger, o.TaskID, lease.Options{
+		Duration:         time.Duration(o.Duration) * time.Second,
+		RenewBefore:      time.Duration(o.RenewBefore) * time.Second,
+		AvailabilityMode: options.AvailabilityModeFromString(o.AvailabilityMode),
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := o.writeTaskID(o.TaskID); err != nil {
+		return err
+	}
+
+	logger.Infof("Holding locks of maintenance task %s", o.TaskID)
+	taskID := o.TaskID
+	for {
+		if _, err := keeper.Tick(); err != nil {
+			logger.Warnf("Failed to keep locks of task %s alive: %v", keeper.TaskID(), err)
+		}
+
+		if keeper.TaskID() != taskID {
+			taskID = keeper.TaskID()
+			fmt.Printf("Your task id is now:\n\n%s\n\n", taskID)
+			if err := o.writeTaskID(taskID); err != nil {
+				logger.Warnf("%v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return o.release(keeper, logger)
+		case <-time.After(time.Duration(o.CMSQueryInterval) * time.Second):
+		}
+	}
+}
+
+func (o *Options) release(keeper *lease.Keeper, logger *zap.SugaredLogger) error {
+	switch o.OnExit {
+	case OnExitComplete:
+		logger.Infof("Interrupted, completing maintenance task %s", keeper.TaskID())
+		return keeper.Release(true)
+	case OnExitDrop:
+		logger.Infof("Interrupted, dropping maintenance task %s", keeper.TaskID())
+		return keeper.Release(false)
+	default:
+		logger.Infof("Interrupted, maintenance task %s is left as is", keeper.TaskID())
+		return nil
+	}
+}
+
+// writeTaskID replaces the content of --task-id-file, so that it is never read half-written.
+func (o *Options) writeTaskID(taskID string) error {
+	if o.TaskIDFile == "" {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(o.TaskIDFile), filepath.Base(o.TaskIDFile)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write task id %s to %s: %w", taskID, o.TaskIDFile, err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.WriteString(taskID + "\n"); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write task id %s to %s: %w", taskID, o.TaskIDFile, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write task id %s to %s: %w", taskID, o.TaskIDFile, err)
+	}
+	if err = os.Rename(tmp.Name(), o.TaskIDFile); err != nil {
+		return fmt.Errorf("failed to write task id %s to %s: %w", taskID, o.TaskIDFile, err)
+	}
+	return nil
+}
+
+// startDaemon runs the same command line without --daemon as a detached process.
+func startDaemon(logFile, taskIDFile, taskID string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate ydbops executable: %w", err)
+	}
+
+	log, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", logFile, err)
+	}
+	defer log.Close()
+
+	args := []string{}
+	for _, arg := range os.Args[1:] {
+		if arg != "--daemon" && arg != "--daemon=true" {
+			args = append(args, arg)
+		}
+	}
+
+	process, err := os.StartProcess(executable, append([]string{executable}, args...), &os.ProcAttr{
+		Env:   os.Environ(),
+		Files: []*os.File{nil, log, log},
+		Sys:   &syscall.SysProcAttr{Setsid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start the lease keeper in background: %w", err)
+	}
+
+	fmt.Printf(
+		"Holding locks of task %s in background, pid %d, logs in %s, the id of the task holding the locks in %s\n",
+		taskID, process.Pid, logFile, taskIDFile,
+	)
+	return process.Release()
+}
@@ -1,6 +1,8 @@
 package list
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 
 	"github.com/ydb-platform/ydbops/pkg/cli"
@@ -14,15 +16,22 @@ func New(f cmdutil.Factory) *cobra.Command {
 		Use:   "list",
 		Short: "List all existing maintenance tasks",
 		Long: `ydbops maintenance list:
-  List all existing maintenance tasks on the cluster.
-  Can be useful if you lost your task id to refresh/complete your own task.`,
-		PreRunE: cli.PopulateProfileDefaultsAndValidate(
-			f.GetBaseOptions(),
-		),
+  List existing maintenance tasks on the cluster, yours by default.
+  Can be useful if you lost your task id to refresh/complete your own task,
+  or, with --all-users, to find out which tasks hold locks on which nodes.
+  CMS does not report task owners, use --user to list tasks of a specific user.
+  The user to authenticate as goes before the command then:
+  'ydbops --user admin maintenance list --user <sid>'.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			opts.splitUserFlags(cmd, os.Args[1:])
+			return cli.PopulateProfileDefaultsAndValidate(f.GetBaseOptions(), opts)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return opts.Run(f)
 		},
 	})
 
+	opts.DefineFlags(cmd.PersistentFlags())
+
 	return cmd
 }
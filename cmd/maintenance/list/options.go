@@ -2,32 +2,411 @@ package list
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ydb-platform/ydbops/internal/collections"
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
 	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/options"
 	"github.com/ydb-platform/ydbops/pkg/prettyprint"
+	"github.com/ydb-platform/ydbops/pkg/utils"
 )
 
-type Options struct{}
+var actionStatuses = []string{"pending", "performed"}
+
+type Options struct {
+	AllUsers bool
+	User     string
+	Hosts    []string
+	Statuses []string
+	MinAge   int
+	MaxAge   int
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.AllUsers, "all-users", false,
+		"List maintenance tasks of all users, not only yours")
+
+	fs.StringVar(&o.User, "user", "",
+		"List maintenance tasks of the user with this SID instead of yours")
+
+	fs.StringSliceVar(&o.Hosts, "hosts", []string{},
+		`Only show actions on these hosts. You can specify a list of host FQDNs or a list of node ids,
+  but you can not mix host FQDNs and node ids in this option. The list is comma-delimited.
+  E.g.: '--hosts=1,2,3' or '--hosts=fqdn1,fqdn2,fqdn3'`)
+
+	fs.StringSliceVar(&o.Statuses, "status", []string{},
+		fmt.Sprintf("Only show actions in these statuses. Available choices: %s", strings.Join(actionStatuses, ", ")))
+
+	fs.IntVar(&o.MinAge, "min-age", 0,
+		"Only show tasks created at least this many seconds ago")
+
+	fs.IntVar(&o.MaxAge, "max-age", 0,
+		"Only show tasks created at most this many seconds ago. 0 means no limit")
+}
+
+// splitUserFlags tells the --user of the command from the global --user, which it
+// shadows: cobra parses the whole command line with the flags of the command.
+// The global --user goes before the command name and is the user to authenticate
+// as, the --user after it selects whose tasks are listed.
+func (o *Options) splitUserFlags(cmd *cobra.Command, args []string) {
+	names := []string{}
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		names = append([]string{c.Name()}, names...)
+	}
+
+	user, matched := "", 0
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case matched < len(names) && arg == names[matched]:
+			matched++
+			if matched == len(names) && user != "" {
+				options.Auths[options.Static].(*options.AuthStatic).User = user
+				user = ""
+			}
+		case arg == "--user" && i+1 < len(args):
+			user = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--user="):
+			user = strings.TrimPrefix(arg, "--user=")
+		}
+	}
+	o.User = user
+}
+
+func (o *Options) Validate() error {
+	if o.AllUsers && o.User != "" {
+		return fmt.Errorf("--all-users and --user can not be specified together")
+	}
+	for _, status := range o.Statuses {
+		if !collections.Contains(actionStatuses, strings.ToLower(status)) {
+			return fmt.Errorf("specified a non-existing action status: %s", status)
+		}
+	}
+	if o.MinAge < 0 {
+		return fmt.Errorf("specified invalid --min-age: %d. Must be positive", o.MinAge)
+	}
+	if o.MaxAge < 0 {
+		return fmt.Errorf("specified invalid --max-age: %d. Must be positive", o.MaxAge)
+	}
+	return nil
+}
+
+func (o *Options) filtersSpecified() bool {
+	return len(o.Hosts) > 0 || len(o.Statuses) > 0 || o.MinAge > 0 || o.MaxAge > 0
+}
 
 func (o *Options) Run(f cmdutil.Factory) error {
-	userSID, err := f.GetDiscoveryClient().WhoAmI()
+	userSID := o.User
+	if !o.AllUsers && userSID == "" {
+		var err error
+		userSID, err = f.GetDiscoveryClient().WhoAmI()
+		if err != nil {
+			return err
+		}
+	}
+
+	tasks, err := f.GetCMSClient().MaintenanceTasks(userSID)
 	if err != nil {
 		return err
 	}
 
-	tasks, err := f.GetCMSClient().MaintenanceTasks(userSID)
+	var resolver *scopeResolver
+	if len(tasks) > 0 {
+		nodes, err := f.GetCMSClient().Nodes()
+		if err != nil {
+			return err
+		}
+		resolver = newScopeResolver(nodes)
+	}
+
+	filter, err := o.newActionFilter(resolver)
 	if err != nil {
 		return err
 	}
 
-	if len(tasks) == 0 {
-		fmt.Println("There are no maintenance tasks, associated with your user, at the moment.")
-	} else {
-		for _, task := range tasks {
-			taskInfo := prettyprint.TaskToString(task)
-			fmt.Println(taskInfo)
+	now := time.Now()
+	listed := make([]cms.MaintenanceTask, 0, len(tasks))
+	for _, task := range tasks {
+		if !o.ageMatches(task, now) {
+			continue
+		}
+
+		filtered := filter.apply(task)
+		if len(filtered.GetActionGroupStates()) == 0 && o.filtersSpecified() {
+			continue
+		}
+		listed = append(listed, filtered)
+	}
+
+	if output := f.GetBaseOptions().Output; output != options.OutputText {
+		structured := make([]prettyprint.Task, 0, len(listed))
+		for _, task := range listed {
+			t := prettyprint.TaskToStructured(task)
+			for i := range t.ActionGroups {
+				for j := range t.ActionGroups[i].Actions {
+					action := &t.ActionGroups[i].Actions[j]
+					action.Location = resolver.locate(action.NodeID, action.Host)
+				}
+			}
+			structured = append(structured, t)
+		}
+
+		content, err := prettyprint.Marshal(output, structured)
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+		return nil
+	}
+
+	if len(listed) == 0 {
+		switch {
+		case o.filtersSpecified():
+			fmt.Println("There are no maintenance tasks matching the filters at the moment.")
+		case userSID == "":
+			fmt.Println("There are no maintenance tasks on the cluster at the moment.")
+		case o.User != "":
+			fmt.Printf("There are no maintenance tasks of user %s at the moment.\n", o.User)
+		default:
+			fmt.Println("There are no maintenance tasks, associated with your user, at the moment.")
+		}
+		return nil
+	}
+
+	for _, task := range listed {
+		fmt.Println(prettyprint.ListedTaskToString(task, resolver.locate, now))
+	}
+
+	return nil
+}
+
+func (o *Options) ageMatches(task cms.MaintenanceTask, now time.Time) bool {
+	if o.MinAge == 0 && o.MaxAge == 0 {
+		return true
+	}
+
+	createTime, ok := cms.TaskCreateTime(task)
+	if !ok {
+		return false
+	}
+
+	age := now.Sub(createTime)
+	if age < time.Duration(o.MinAge)*time.Second {
+		return false
+	}
+	if o.MaxAge > 0 && age > time.Duration(o.MaxAge)*time.Second {
+		return false
+	}
+	return true
+}
+
+// actionFilter narrows down the actions of a task to the requested hosts and statuses.
+type actionFilter struct {
+	resolver *scopeResolver
+	nodeIDs  map[uint32]bool
+	hosts    map[string]bool
+	statuses map[Ydb_Maintenance.ActionState_ActionStatus]bool
+}
+
+func (o *Options) newActionFilter(resolver *scopeResolver) (*actionFilter, error) {
+	filter := &actionFilter{
+		resolver: resolver,
+	}
+
+	if len(o.Hosts) > 0 {
+		nodeIDs, errIds := utils.GetNodeIds(o.Hosts)
+		hostFQDNs, errFqdns := utils.GetNodeFQDNs(o.Hosts)
+		if errIds != nil && errFqdns != nil {
+			return nil, fmt.Errorf(
+				"failed to parse --hosts argument as node ids (%w) or host fqdns (%w)",
+				errIds,
+				errFqdns,
+			)
+		}
+
+		if errIds == nil {
+			filter.nodeIDs = make(map[uint32]bool)
+			for _, nodeID := range nodeIDs {
+				filter.nodeIDs[nodeID] = true
+			}
+		} else {
+			filter.hosts = make(map[string]bool)
+			for _, host := range hostFQDNs {
+				filter.hosts[host] = true
+			}
+		}
+	}
+
+	if len(o.Statuses) > 0 {
+		filter.statuses = make(map[Ydb_Maintenance.ActionState_ActionStatus]bool)
+		for _, status := range o.Statuses {
+			name := "ACTION_STATUS_" + strings.ToUpper(status)
+			filter.statuses[Ydb_Maintenance.ActionState_ActionStatus(Ydb_Maintenance.ActionState_ActionStatus_value[name])] = true
 		}
 	}
 
+	return filter, nil
+}
+
+func (f *actionFilter) matches(as *Ydb_Maintenance.ActionState) bool {
+	if f.statuses != nil && !f.statuses[as.GetStatus()] {
+		return false
+	}
+
+	nodeID, host := cms.ScopeNodeAndHost(as.GetAction().GetLockAction().GetScope())
+	if f.nodeIDs != nil {
+		nodeIDs := f.resolver.nodeIDsOf(nodeID, host)
+		if !slicesContainAny(nodeIDs, f.nodeIDs) {
+			return false
+		}
+	}
+	if f.hosts != nil {
+		if !f.hosts[f.resolver.hostOf(nodeID, host)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *actionFilter) apply(task cms.MaintenanceTask) cms.MaintenanceTask {
+	filtered := &filteredTask{
+		MaintenanceTask: task,
+	}
+
+	for _, gs := range task.GetActionGroupStates() {
+		states := []*Ydb_Maintenance.ActionState{}
+		for _, as := range gs.GetActionStates() {
+			if f.matches(as) {
+				states = append(states, as)
+			}
+		}
+		if len(states) > 0 {
+			filtered.groups = append(filtered.groups, &Ydb_Maintenance.ActionGroupStates{ActionStates: states})
+		}
+	}
+
+	return filtered
+}
+
+// filteredTask is a task with only a subset of its actions visible.
+type filteredTask struct {
+	cms.MaintenanceTask
+	groups []*Ydb_Maintenance.ActionGroupStates
+}
+
+func (t *filteredTask) GetActionGroupStates() []*Ydb_Maintenance.ActionGroupStates {
+	return t.groups
+}
+
+func (t *filteredTask) GetTaskOptions() *Ydb_Maintenance.MaintenanceTaskOptions {
+	if info, ok := t.MaintenanceTask.(cms.MaintenanceTaskInfo); ok {
+		return info.GetTaskOptions()
+	}
+	return nil
+}
+
+func (t *filteredTask) GetCreateTime() *timestamppb.Timestamp {
+	if info, ok := t.MaintenanceTask.(cms.MaintenanceTaskInfo); ok {
+		return info.GetCreateTime()
+	}
+	return nil
+}
+
+func (t *filteredTask) GetLastRefreshTime() *timestamppb.Timestamp {
+	if info, ok := t.MaintenanceTask.(cms.MaintenanceTaskInfo); ok {
+		return info.GetLastRefreshTime()
+	}
 	return nil
 }
+
+// scopeResolver maps action scopes (node ids or host FQDNs) back to cluster nodes.
+type scopeResolver struct {
+	nodesByID   map[uint32]*Ydb_Maintenance.Node
+	nodesByHost map[string][]*Ydb_Maintenance.Node
+}
+
+func newScopeResolver(nodes []*Ydb_Maintenance.Node) *scopeResolver {
+	r := &scopeResolver{
+		nodesByID:   make(map[uint32]*Ydb_Maintenance.Node),
+		nodesByHost: make(map[string][]*Ydb_Maintenance.Node),
+	}
+	for _, node := range nodes {
+		r.nodesByID[node.GetNodeId()] = node
+		r.nodesByHost[node.GetHost()] = append(r.nodesByHost[node.GetHost()], node)
+	}
+	return r
+}
+
+func (r *scopeResolver) nodesOf(nodeID uint32, host string) []*Ydb_Maintenance.Node {
+	if r == nil {
+		return nil
+	}
+	if nodeID != 0 {
+		if node, ok := r.nodesByID[nodeID]; ok {
+			return []*Ydb_Maintenance.Node{node}
+		}
+		return nil
+	}
+	return r.nodesByHost[host]
+}
+
+func (r *scopeResolver) nodeIDsOf(nodeID uint32, host string) []uint32 {
+	if nodeID != 0 {
+		return []uint32{nodeID}
+	}
+	return collections.Convert(r.nodesOf(nodeID, host), func(n *Ydb_Maintenance.Node) uint32 {
+		return n.GetNodeId()
+	})
+}
+
+func (r *scopeResolver) hostOf(nodeID uint32, host string) string {
+	if host != "" {
+		return host
+	}
+	if nodes := r.nodesOf(nodeID, host); len(nodes) > 0 {
+		return nodes[0].GetHost()
+	}
+	return ""
+}
+
+func (r *scopeResolver) locate(nodeID uint32, host string) *prettyprint.Location {
+	nodes := r.nodesOf(nodeID, host)
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	location := &prettyprint.Location{
+		Host:       nodes[0].GetHost(),
+		DataCenter: nodes[0].GetLocation().GetDataCenter(),
+	}
+
+	tenants := make(map[string]bool)
+	for _, node := range nodes {
+		if tenant := node.GetDynamic().GetTenant(); tenant != "" {
+			tenants[tenant] = true
+		}
+	}
+	location.Tenants = collections.Keys(tenants)
+	sort.Strings(location.Tenants)
+
+	return location
+}
+
+func slicesContainAny(values []uint32, set map[uint32]bool) bool {
+	for _, v := range values {
+		if set[v] {
+			return true
+		}
+	}
+	return false
+}
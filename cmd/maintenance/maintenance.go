@@ -6,8 +6,10 @@ import (
 	"github.com/ydb-platform/ydbops/cmd/maintenance/complete"
 	"github.com/ydb-platform/ydbops/cmd/maintenance/create"
 	"github.com/ydb-platform/ydbops/cmd/maintenance/drop"
+	"github.com/ydb-platform/ydbops/cmd/maintenance/hold"
 	"github.com/ydb-platform/ydbops/cmd/maintenance/list"
 	"github.com/ydb-platform/ydbops/cmd/maintenance/refresh"
+	"github.com/ydb-platform/ydbops/cmd/maintenance/wait"
 	"github.com/ydb-platform/ydbops/pkg/cli"
 	"github.com/ydb-platform/ydbops/pkg/cmdutil"
 )
@@ -26,8 +28,10 @@ func New(f cmdutil.Factory) *cobra.Command {
 		complete.New(f),
 		create.New(f),
 		drop.New(f),
+		hold.New(f),
 		list.New(f),
 		refresh.New(f),
+		wait.New(f),
 	)
 
 	return cmd
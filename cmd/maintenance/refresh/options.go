@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/options"
 	"github.com/ydb-platform/ydbops/pkg/prettyprint"
 )
 
@@ -31,6 +32,15 @@ func (o *Options) Run(f cmdutil.Factory) error {
 		return err
 	}
 
+	if output := f.GetBaseOptions().Output; output != options.OutputText {
+		content, err := prettyprint.Marshal(output, prettyprint.TaskToStructured(task))
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+		return nil
+	}
+
 	fmt.Println(prettyprint.TaskToString(task))
 
 	return nil
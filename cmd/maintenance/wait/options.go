@@ -0,0 +1,177 @@
+package wait
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/prettyprint"
+)
+
+const (
+	DefaultCMSQueryIntervalSeconds = 10
+)
+
+type Options struct {
+	TaskID           string
+	Hosts            []string
+	Timeout          int
+	CMSQueryInterval int
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.TaskID, "task-id", "",
+		"ID of your maintenance task (result of `ydbops maintenance host`)")
+
+	fs.StringSliceVar(&o.Hosts, "hosts", []string{},
+		`Wait only for actions on these hosts. You can specify a list of host FQDNs or a list of node ids,
+  but you can not mix host FQDNs and node ids in this option. The list is comma-delimited.
+  E.g.: '--hosts=1,2,3' or '--hosts=fqdn1,fqdn2,fqdn3'. By default waits for all actions of the task`)
+
+	fs.IntVar(&o.Timeout, "timeout", 0,
+		"Give up waiting after this many seconds and exit with an error. 0 means wait indefinitely")
+
+	fs.IntVar(&o.CMSQueryInterval, "cms-query-interval", DefaultCMSQueryIntervalSeconds,
+		fmt.Sprintf("How often to query CMS while waiting for actions to be performed %v", DefaultCMSQueryIntervalSeconds))
+}
+
+func (o *Options) Validate() error {
+	if o.TaskID == "" {
+		return fmt.Errorf("--task-id unspecified, argument required")
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("specified invalid timeout: %d. Must be positive", o.Timeout)
+	}
+	if o.CMSQueryInterval <= 0 {
+		return fmt.Errorf("specified invalid cms query interval seconds: %d. Must be positive", o.CMSQueryInterval)
+	}
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	ctx := context.Background()
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(o.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	output := f.GetBaseOptions().Output
+	lastReported := make(map[string]string)
+
+	var (
+		awaited    []*Ydb_Maintenance.ActionState
+		pending    int
+		refreshErr error
+	)
+	for {
+		delay := time.Duration(o.CMSQueryInterval) * time.Second
+
+		var task cms.MaintenanceTask
+		task, refreshErr = f.GetCMSClient().RefreshTask(o.TaskID)
+		if refreshErr != nil {
+			if cms.IsPermanentError(refreshErr) {
+				return refreshErr
+			}
+			// the wait may take hours, a single failed refresh must not end it
+			zap.S().Warnf("Failed to refresh task %s, will retry in %s: %v", o.TaskID, delay, refreshErr)
+		} else {
+			var err error
+			if awaited, err = o.selectActions(task); err != nil {
+				return err
+			}
+
+			pending = o.reportProgress(output, awaited, lastReported)
+			if pending == 0 {
+				return o.printResult(output, task, len(awaited))
+			}
+
+			if task.GetRetryAfter() != nil {
+				if retryDelay := time.Until(task.GetRetryAfter().AsTime()); retryDelay > delay {
+					delay = retryDelay
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return ctx.Err()
+			}
+			if refreshErr != nil {
+				return fmt.Errorf("timed out after %ds waiting for task %s, the last refresh has failed: %w",
+					o.Timeout, o.TaskID, refreshErr)
+			}
+			return fmt.Errorf(
+				"timed out after %ds waiting for task %s: %d of %d actions are not PERFORMED yet",
+				o.Timeout, o.TaskID, pending, len(awaited),
+			)
+		case <-time.After(delay):
+		}
+	}
+}
+
+// reportProgress prints the actions whose state has changed since the last
+// report and returns how many of them are not PERFORMED yet.
+func (o *Options) reportProgress(
+	output string,
+	awaited []*Ydb_Maintenance.ActionState,
+	lastReported map[string]string,
+) int {
+	pending := 0
+	for _, as := range awaited {
+		if as.GetStatus() != Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
+			pending++
+		}
+
+		if output != options.OutputText {
+			continue
+		}
+		progress := prettyprint.ActionStateToString(as)
+		if lastReported[as.GetActionUid().GetActionId()] != progress {
+			lastReported[as.GetActionUid().GetActionId()] = progress
+			fmt.Println(progress)
+		}
+	}
+	return pending
+}
+
+// selectActions returns the lock actions of the task on the requested hosts,
+// or all lock actions if --hosts is unspecified.
+func (o *Options) selectActions(task cms.MaintenanceTask) ([]*Ydb_Maintenance.ActionState, error) {
+	all := cms.LockActions(task)
+	if len(o.Hosts) == 0 {
+		return all, nil
+	}
+
+	selected, missing, err := cms.SelectByHosts(all, o.Hosts)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("host %s is not a part of maintenance task %s", missing[0], o.TaskID)
+	}
+	return selected, nil
+}
+
+func (o *Options) printResult(output string, task cms.MaintenanceTask, performed int) error {
+	if output != options.OutputText {
+		content, err := prettyprint.Marshal(output, prettyprint.TaskToStructured(task))
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+		return nil
+	}
+
+	fmt.Printf("All %d awaited actions of task %s are PERFORMED\n", performed, o.TaskID)
+	return nil
+}
@@ -0,0 +1,31 @@
+package wait
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "wait",
+		Short: "Wait until CMS performs the actions of a maintenance task",
+		Long: `ydbops maintenance wait:
+  Periodically refreshes the maintenance task until the requested actions
+  (or all of them, if --hosts is unspecified) are PERFORMED.
+  Exits with a non-zero code if --timeout expires first.`,
+		PreRunE: cli.PopulateProfileDefaultsAndValidate(
+			f.GetBaseOptions(), opts,
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.PersistentFlags())
+
+	return cmd
+}
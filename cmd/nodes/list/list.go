@@ -0,0 +1,30 @@
+package list
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "list",
+		Short: "List cluster nodes",
+		Long: `ydbops nodes list:
+  List cluster nodes reported by CMS, together with summary counts
+  per version, datacenter and tenant. Accepts the same filters as restart.`,
+		PreRunE: cli.PopulateProfileDefaultsAndValidate(
+			f.GetBaseOptions(), opts,
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.PersistentFlags())
+
+	return cmd
+}
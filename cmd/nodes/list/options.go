@@ -0,0 +1,122 @@
+package list
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ydb-platform/ydbops/internal/collections"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/inventory"
+	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/prettyprint"
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = options.OutputJSON
+	FormatYAML  = options.OutputYAML
+	FormatCSV   = "csv"
+)
+
+var formats = []string{FormatTable, FormatJSON, FormatYAML, FormatCSV}
+
+type Options struct {
+	options.TargetingOptions
+
+	Format string
+}
+
+type listResult struct {
+	Nodes   []inventory.NodeInfo `json:"nodes" yaml:"nodes"`
+	Summary inventory.Summary    `json:"summary" yaml:"summary"`
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	o.TargetingOptions.DefineFlags(fs)
+
+	fs.StringVar(&o.Format, "format", "",
+		fmt.Sprintf(`Output format. Available choices: %s.
+If unspecified, follows the global --output option, where text means table`, strings.Join(formats, ", ")))
+}
+
+func (o *Options) Validate() error {
+	if o.Format != "" && !collections.Contains(formats, o.Format) {
+		return fmt.Errorf("specified a non-existing output format: %s", o.Format)
+	}
+
+	return o.TargetingOptions.Validate()
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	nodes, err := f.GetCMSClient().Nodes()
+	if err != nil {
+		return err
+	}
+
+	targetedNodes, err := restarters.SelectNodes(&o.TargetingOptions, nodes)
+	if err != nil {
+		return err
+	}
+
+	infos := inventory.FromNodes(targetedNodes, time.Now())
+	result := listResult{
+		Nodes:   infos,
+		Summary: inventory.Summarize(infos),
+	}
+
+	format := o.Format
+	if format == "" {
+		format = f.GetBaseOptions().Output
+	}
+
+	switch format {
+	case FormatJSON, FormatYAML:
+		content, err := prettyprint.Marshal(format, result)
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+	case FormatCSV:
+		return writeCSV(infos)
+	default:
+		fmt.Print(prettyprint.NodesToTable(infos))
+		fmt.Println()
+		fmt.Print(prettyprint.NodesSummaryToString(result.Summary))
+	}
+
+	return nil
+}
+
+func writeCSV(infos []inventory.NodeInfo) error {
+	w := csv.NewWriter(os.Stdout)
+
+	records := [][]string{
+		{"nodeId", "host", "port", "dataCenter", "rack", "type", "tenant", "state", "version", "uptimeSeconds"},
+	}
+	for _, info := range infos {
+		records = append(records, []string{
+			strconv.FormatUint(uint64(info.NodeID), 10),
+			info.Host,
+			strconv.FormatUint(uint64(info.Port), 10),
+			info.DataCenter,
+			info.Rack,
+			info.Type,
+			info.Tenant,
+			info.State,
+			info.Version,
+			strconv.FormatInt(info.UptimeSeconds, 10),
+		})
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return fmt.Errorf("failed to write nodes as csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package nodes
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/cmd/nodes/list"
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "nodes",
+		Short: "Inspect cluster nodes as seen by the Cluster Management System",
+		Long: `ydbops nodes [command]:
+    Inspect cluster nodes: ids, hosts, locations, states, versions and uptimes.`,
+		RunE: cli.RequireSubcommand,
+	})
+
+	cmd.AddCommand(
+		list.New(f),
+	)
+
+	return cmd
+}
@@ -0,0 +1,28 @@
+package activate
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "activate",
+		Short: "Make a profile current",
+		Long: `ydbops profile activate:
+  Make a profile current. The current profile is used by all commands,
+  unless another one is selected with global --profile option.`,
+		PreRunE: cli.ValidateOptions(opts),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.PersistentFlags())
+
+	return cmd
+}
@@ -0,0 +1,46 @@
+package activate
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+type Options struct {
+	Name string
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "",
+		"Name of the profile to make current")
+}
+
+func (o *Options) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("--name unspecified, argument required")
+	}
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	configPath := profile.ResolveConfigPath(f.GetBaseOptions().ProfileFile)
+
+	config, err := profile.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err = config.Activate(o.Name); err != nil {
+		return err
+	}
+
+	if err = profile.SaveConfig(configPath, config); err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile `%s` is now current\n", o.Name)
+	return nil
+}
@@ -0,0 +1,28 @@
+package create
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "create",
+		Short: "Create a new profile",
+		Long: `ydbops profile create:
+  Create a new profile in the profile file. The profile file is created if it does not exist.
+  The first profile in the file automatically becomes the current one.`,
+		PreRunE: cli.ValidateOptions(opts),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.PersistentFlags())
+
+	return cmd
+}
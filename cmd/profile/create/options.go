@@ -0,0 +1,63 @@
+package create
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+type Options struct {
+	Name     string
+	Values   map[string]string
+	Activate bool
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "",
+		"Name of the profile to create")
+	fs.StringToStringVar(&o.Values, "set", map[string]string{},
+		`Profile options in key=value format. The list is comma-delimited. Keys are names of
+options that can be specified in a profile, e.g. endpoint, user, ca-file, kubeconfig.
+  E.g.: '--set endpoint=grpcs://my-cluster:2135,user=admin'`)
+	fs.BoolVar(&o.Activate, "activate", false,
+		"Make the new profile current")
+}
+
+func (o *Options) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("--name unspecified, argument required")
+	}
+	return profile.Profile(o.Values).Validate()
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	configPath := profile.ResolveConfigPath(f.GetBaseOptions().ProfileFile)
+
+	config, err := profile.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := config.Profiles[o.Name]; exists {
+		return fmt.Errorf("profile `%s` already exists, use `ydbops profile set` to modify it", o.Name)
+	}
+
+	config.Profiles[o.Name] = profile.Profile(o.Values)
+	if o.Activate || config.CurrentProfile == "" {
+		config.CurrentProfile = o.Name
+	}
+
+	if err = config.CheckInheritance(o.Name); err != nil {
+		return fmt.Errorf("profile `%s` is not created: %w", o.Name, err)
+	}
+
+	if err = profile.SaveConfig(configPath, config); err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile `%s` created in %s\n", o.Name, configPath)
+	return nil
+}
@@ -0,0 +1,34 @@
+package list
+
+import (
+	"fmt"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+type Options struct{}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	configPath := profile.ResolveConfigPath(f.GetBaseOptions().ProfileFile)
+
+	config, err := profile.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if len(config.Profiles) == 0 {
+		fmt.Printf("There are no profiles in %s at the moment.\n", configPath)
+		return nil
+	}
+
+	for _, name := range config.ProfileNames() {
+		marker := " "
+		if name == config.CurrentProfile {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package profile
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/cmd/profile/activate"
+	"github.com/ydb-platform/ydbops/cmd/profile/create"
+	"github.com/ydb-platform/ydbops/cmd/profile/list"
+	"github.com/ydb-platform/ydbops/cmd/profile/remove"
+	"github.com/ydb-platform/ydbops/cmd/profile/set"
+	"github.com/ydb-platform/ydbops/cmd/profile/show"
+	"github.com/ydb-platform/ydbops/cmd/profile/validate"
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "profile",
+		Short: "Manage profiles in the profile file",
+		Long: `ydbops profile [command]:
+    Manage profiles stored in the profile file (see --profile-file).
+    By default, $HOME/ydb/ydbops/config/config.yaml is used.`,
+		RunE: cli.RequireSubcommand,
+	})
+
+	cmd.AddCommand(
+		activate.New(f),
+		create.New(f),
+		remove.New(f),
+		list.New(f),
+		set.New(f),
+		show.New(f),
+		validate.New(f),
+	)
+
+	return cmd
+}
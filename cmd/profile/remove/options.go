@@ -0,0 +1,50 @@
+package remove
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+type Options struct {
+	Name string
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "",
+		"Name of the profile to delete")
+}
+
+func (o *Options) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("--name unspecified, argument required")
+	}
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	configPath := profile.ResolveConfigPath(f.GetBaseOptions().ProfileFile)
+
+	config, err := profile.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	wasCurrent := config.CurrentProfile == o.Name
+	if err = config.Delete(o.Name); err != nil {
+		return err
+	}
+
+	if err = profile.SaveConfig(configPath, config); err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile `%s` deleted\n", o.Name)
+	if wasCurrent {
+		fmt.Println("It was the current profile, use `ydbops profile activate` to select a new one.")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package remove
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "delete",
+		Short: "Delete a profile",
+		Long: `ydbops profile delete:
+  Delete a profile from the profile file. If the deleted profile was the current one,
+  no profile will be current afterwards.`,
+		PreRunE: cli.ValidateOptions(opts),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.PersistentFlags())
+
+	return cmd
+}
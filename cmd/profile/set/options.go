@@ -0,0 +1,85 @@
+package set
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+type Options struct {
+	Name   string
+	Values map[string]string
+	Unset  []string
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "",
+		"Name of the profile to change, it is created if it does not exist")
+	fs.StringToStringVar(&o.Values, "set", map[string]string{},
+		`Profile options in key=value format. The list is comma-delimited. Keys are names of
+options that can be specified in a profile, e.g. endpoint, user, ca-file, kubeconfig.
+  E.g.: '--set endpoint=grpcs://my-cluster:2135,user=admin'`)
+	fs.StringSliceVar(&o.Unset, "unset", []string{},
+		`Comma-delimited list of options to remove from the profile.
+  E.g.: '--unset kubeconfig,k8s-namespace'`)
+}
+
+func (o *Options) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("--name unspecified, argument required")
+	}
+	if len(o.Values) == 0 && len(o.Unset) == 0 {
+		return fmt.Errorf("nothing to change, specify --set or --unset")
+	}
+	for _, key := range o.Unset {
+		if _, present := o.Values[key]; present {
+			return fmt.Errorf("option `%s` is specified both in --set and --unset", key)
+		}
+	}
+	return profile.Profile(o.Values).Validate()
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	configPath := profile.ResolveConfigPath(f.GetBaseOptions().ProfileFile)
+
+	config, err := profile.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	p, err := config.Get(o.Name)
+	created := errors.Is(err, profile.ErrProfileNotFound)
+	switch {
+	case created:
+		p = profile.Profile{}
+		config.Profiles[o.Name] = p
+	case err != nil:
+		return err
+	}
+
+	for key, value := range o.Values {
+		p[key] = value
+	}
+	for _, key := range o.Unset {
+		delete(p, key)
+	}
+
+	if err = config.CheckInheritance(o.Name); err != nil {
+		return fmt.Errorf("profile `%s` is not saved: %w", o.Name, err)
+	}
+
+	if err = profile.SaveConfig(configPath, config); err != nil {
+		return err
+	}
+
+	if created {
+		fmt.Printf("Profile `%s` created\n", o.Name)
+		return nil
+	}
+	fmt.Printf("Profile `%s` updated\n", o.Name)
+	return nil
+}
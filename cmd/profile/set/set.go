@@ -0,0 +1,29 @@
+package set
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "set",
+		Short: "Change options of a profile",
+		Long: `ydbops profile set:
+  Set or unset options of a profile, the profile is created if it does not exist.
+  The profile file is rewritten as a whole: comments, key order and keys that
+  ydbops does not know are not preserved.`,
+		PreRunE: cli.ValidateOptions(opts),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.PersistentFlags())
+
+	return cmd
+}
@@ -0,0 +1,71 @@
+package show
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+type Options struct {
+	Name        string
+	ShowSecrets bool
+	Resolved    bool
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "",
+		"Name of the profile to show. Default: the active profile")
+	fs.BoolVar(&o.ShowSecrets, "show-secrets", false,
+		"Do not mask values of credential options")
+	fs.BoolVar(&o.Resolved, "resolved", false,
+		"Show the profile as ydbops sees it: with extends applied and ${...} references substituted")
+}
+
+func (o *Options) Validate() error {
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	configPath := profile.ResolveConfigPath(f.GetBaseOptions().ProfileFile)
+
+	config, err := profile.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	name := o.Name
+	if name == "" {
+		name = f.GetBaseOptions().ActiveProfile
+	}
+	name, err = config.ResolveName(name)
+	if err != nil {
+		return err
+	}
+
+	var p profile.Profile
+	if o.Resolved {
+		p, err = config.Resolve(name)
+	} else {
+		p, err = config.Get(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !o.ShowSecrets {
+		p = p.Masked()
+	}
+
+	content, err := yaml.Marshal(map[string]profile.Profile{name: p})
+	if err != nil {
+		return fmt.Errorf("failed to serialize profile `%s`: %w", name, err)
+	}
+
+	fmt.Print(string(content))
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package show
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+)
+
+func New(f cmdutil.Factory) *cobra.Command {
+	opts := &Options{}
+
+	cmd := cli.SetDefaultsOn(&cobra.Command{
+		Use:   "show",
+		Short: "Show the contents of a profile",
+		Long: `ydbops profile show:
+  Show the contents of a profile. If --name is not specified, the profile selected
+  with global --profile option or the current profile is shown.
+  Values of credential options are masked unless --show-secrets is specified.
+  With --resolved, inherited options and substituted ${...} references are shown.`,
+		PreRunE: cli.ValidateOptions(opts),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Run(f)
+		},
+	})
+
+	opts.DefineFlags(cmd.PersistentFlags())
+
+	return cmd
+}
@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+type Options struct {
+	Name string
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Name, "name", "",
+		"Name of the profile to check. Default: check the whole profile file")
+}
+
+func (o *Options) Validate() error {
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	configPath := profile.ResolveConfigPath(f.GetBaseOptions().ProfileFile)
+
+	if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("profile file %s does not exist", configPath)
+	}
+
+	config, err := profile.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	if o.Name == "" {
+		if err = config.Validate(); err != nil {
+			return err
+		}
+		fmt.Printf("Profile file %s is valid\n", configPath)
+		return nil
+	}
+
+	p, err := config.Get(o.Name)
+	if err != nil {
+		return err
+	}
+	if err = p.Validate(); err != nil {
+		return fmt.Errorf("profile `%s`: %w", o.Name, err)
+	}
+	if _, err = config.Resolve(o.Name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile `%s` is valid\n", o.Name)
+	return nil
+}
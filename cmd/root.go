@@ -9,9 +9,14 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/ydb-platform/ydbops/cmd/config"
+	"github.com/ydb-platform/ydbops/cmd/exec"
 	"github.com/ydb-platform/ydbops/cmd/maintenance"
+	"github.com/ydb-platform/ydbops/cmd/nodes"
+	"github.com/ydb-platform/ydbops/cmd/profile"
 	"github.com/ydb-platform/ydbops/cmd/restart"
 	"github.com/ydb-platform/ydbops/cmd/run"
+	"github.com/ydb-platform/ydbops/cmd/upgrade"
 	"github.com/ydb-platform/ydbops/cmd/version"
 	iCli "github.com/ydb-platform/ydbops/internal/cli"
 	"github.com/ydb-platform/ydbops/pkg/cli"
@@ -96,7 +101,12 @@ func InitRootCommandTree(root *cobra.Command, f cmdutil.Factory) {
 	root.AddCommand(
 		restart.New(f),
 		maintenance.New(f),
+		nodes.New(f),
 		run.New(f),
+		upgrade.New(f),
+		config.New(f),
+		exec.New(f),
+		profile.New(f),
 		version.New(),
 	)
 }
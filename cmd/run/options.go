@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/pflag"
 	"go.uber.org/zap"
@@ -18,6 +22,9 @@ import (
 type Options struct {
 	*rolling.RestartOptions
 	PayloadFilePath string
+	Remote          bool
+	Sudo            bool
+	PayloadTimeout  int
 }
 
 func (r *Options) DefineFlags(fs *pflag.FlagSet) {
@@ -28,6 +35,13 @@ func (r *Options) DefineFlags(fs *pflag.FlagSet) {
 		"",
 		"File path to arbitrary executable to run in the context of the local machine",
 	)
+	fs.BoolVar(&r.Remote, "remote", false,
+		"Copy the payload to every node over ssh and run it there instead of the local machine. See --ssh-args")
+	fs.BoolVar(&r.Sudo, "sudo", false,
+		"Run the payload with sudo on the node. Requires --remote")
+	fs.IntVar(&r.PayloadTimeout, "payload-timeout", 0,
+		"Kill the payload, together with every process it has started, if it runs longer than this, in seconds. "+
+			"The attempt fails and is retried. 0 means no timeout")
 }
 
 func (r *Options) Validate() error {
@@ -36,6 +50,14 @@ func (r *Options) Validate() error {
 		return err
 	}
 
+	if r.Sudo && !r.Remote {
+		return fmt.Errorf("--sudo can only be used together with --remote")
+	}
+
+	if r.PayloadTimeout < 0 {
+		return fmt.Errorf("specified invalid payload timeout: %d. Must not be negative", r.PayloadTimeout)
+	}
+
 	if r.PayloadFilePath == "" {
 		return fmt.Errorf("empty --payload specified")
 	}
@@ -57,16 +79,53 @@ func (r *Options) Validate() error {
 }
 
 func (r *Options) Run(f cmdutil.Factory) error {
-	bothUnspecified := !r.Storage && !r.Tenant
+	if err := r.ApplyPlan(); err != nil {
+		return err
+	}
 
-	restarter := restarters.NewRunRestarter(zap.S(), &restarters.RunRestarterParams{
-		PayloadFilePath: r.PayloadFilePath,
+	var used []*restarters.RunRestarter
+	err := rolling.RunWithHooks(options.Logger, r.RestartOptions, func() error {
+		var err error
+		used, err = r.execute(f)
+		return err
 	})
 
+	var results []restarters.PayloadResult
+	for _, restarter := range used {
+		results = append(results, restarter.Results()...)
+	}
+	fmt.Print(resultsToString(results))
+	return err
+}
+
+// execute runs the payload on the selected nodes and returns the restarters it
+// has used, they hold the outcome of every payload invocation.
+func (r *Options) execute(f cmdutil.Factory) ([]*restarters.RunRestarter, error) {
+	bothUnspecified := !r.Storage && !r.Tenant
+
+	if r.HostLevel {
+		var storageRestarter, tenantRestarter restarters.Restarter
+		var used []*restarters.RunRestarter
+		if r.Storage || bothUnspecified {
+			restarter := r.newRunRestarter((*restarters.RunRestarter).SetStorageOnly)
+			storageRestarter = restarter
+			used = append(used, restarter)
+		}
+		if r.Tenant || bothUnspecified {
+			restarter := r.newRunRestarter((*restarters.RunRestarter).SetDynnodeOnly)
+			tenantRestarter = restarter
+			used = append(used, restarter)
+		}
+		hostRestarter := restarters.NewHostRestarter(storageRestarter, tenantRestarter)
+		err := rolling.NewExecuter(r.RestartOptions, options.Logger, f.GetCMSClient(), f.GetDiscoveryClient(), hostRestarter).Execute()
+		return used, err
+	}
+
+	restarter := r.newRunRestarter((*restarters.RunRestarter).SetStorageOnly)
+
 	var executer rolling.Executer
 	var err error
 	if r.Storage || bothUnspecified {
-		restarter.SetStorageOnly()
 		executer = rolling.NewExecuter(r.RestartOptions, options.Logger, f.GetCMSClient(), f.GetDiscoveryClient(), restarter)
 		err = executer.Execute()
 	}
@@ -77,5 +136,51 @@ func (r *Options) Run(f cmdutil.Factory) error {
 		err = executer.Execute()
 	}
 
-	return err
+	return []*restarters.RunRestarter{restarter}, err
+}
+
+func (r *Options) newRunRestarter(setScope func(*restarters.RunRestarter)) *restarters.RunRestarter {
+	restarter := restarters.NewRunRestarter(zap.S(), &restarters.RunRestarterParams{
+		PayloadFilePath: r.PayloadFilePath,
+		Timeout:         time.Duration(r.PayloadTimeout) * time.Second,
+		Remote:          r.Remote,
+		Sudo:            r.Sudo,
+		SSHArgs:         r.SSHArgs,
+	})
+	setScope(restarter)
+	return restarter
+}
+
+// resultsToString prints the exit code of every payload invocation,
+// the output has already been logged while the payload ran.
+func resultsToString(results []restarters.PayloadResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE ID\tHOST\tATTEMPT\tEXIT CODE\tSTATUS\tMESSAGE")
+	for _, result := range results {
+		attempt := "-"
+		if result.Attempt > 0 {
+			attempt = strconv.Itoa(result.Attempt)
+		}
+
+		status, message := "-", "-"
+		if result.Report != nil {
+			status = result.Report.Status
+			if result.Report.Message != "" {
+				message = result.Report.Message
+			}
+		}
+		if result.TimedOut {
+			status = "timed out"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n", result.NodeID, result.Host, attempt, result.ExitCode, status, message)
+	}
+	_ = w.Flush()
+
+	return sb.String()
 }
@@ -21,11 +21,29 @@ var RunCommandDescription = command.NewDescription(
 
 		For every node released by CMS, ydbops will execute this payload independently.
 
-		Restart will be treated as successful if your executable finished with a zero
-		return code.
+		With --remote, ydbops copies the payload to the node over ssh instead and runs it
+		there, as the ssh user or with --sudo.
 
 		Certain environment variable will be passed to your executable on each run:
-			$HOSTNAME: the fqdn of the node currently released by CMS.`,
+			$HOSTNAME: the fqdn of the node currently released by CMS.
+			$YDB_NODE_ID, $YDB_NODE_PORT: the node id and its interconnect port.
+			$YDB_TENANT: the tenant of a dynamic node, empty for storage nodes.
+			$YDB_DC, $YDB_RACK: the location of the node.
+			$YDB_VERSION: the ydbd version the node reported before the restart.
+			$YDB_TASK_UID: the maintenance task the node is locked by.
+			$YDB_ATTEMPT: the attempt number, starting from 1, see --restart-retry-number.
+
+		The same description is passed as a JSON object on the payload's stdin.
+
+		Restart will be treated as successful if your executable finished with a zero
+		return code. For more details, the payload may write a single JSON line to
+		file descriptor 3, e.g.:
+			{"status": "failed", "message": "disk is not empty", "retryable": false}
+		Any status other than "ok" fails the attempt. The attempt is retried unless
+		"retryable" is false, then the remaining attempts are skipped.
+
+		The exit code and the status of every payload invocation are printed
+		when the run finishes.`,
 )
 
 func New(
@@ -0,0 +1,174 @@
+package upgrade
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/rolling"
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+)
+
+const (
+	DefaultVersionTimeoutSeconds = 300
+	versionPollInterval          = 5 * time.Second
+)
+
+// the version becomes a part of the binary file name on the nodes
+var safeVersionRegexp = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+
+type Options struct {
+	*rolling.RestartOptions
+
+	Binary         string
+	TargetVersion  string
+	Checksum       string
+	BinaryLink     string
+	VersionTimeout int
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	o.RestartOptions.DefineFlags(fs)
+
+	fs.StringVar(&o.Binary, "binary", "",
+		`Local path or http(s) URL of the new ydbd binary. A local binary is streamed to the nodes
+over ssh stdin, a URL is downloaded by the nodes themselves with curl`)
+
+	fs.StringVar(&o.TargetVersion, "target-version", "",
+		"The version the nodes must report after the upgrade, as shown by 'ydbops nodes list'")
+
+	fs.StringVar(&o.Checksum, "checksum", "",
+		"Expected sha256 of the binary. Computed from the local file if omitted, required for URLs")
+
+	fs.StringVar(&o.BinaryLink, "binary-link", restarters.DefaultBinaryLink,
+		`The symlink systemd units start ydbd by. The new binary is put next to it and the link
+is switched atomically. The previous link target is kept in '<binary-link>.previous' for rollback`)
+
+	fs.IntVar(&o.VersionTimeout, "version-timeout", DefaultVersionTimeoutSeconds,
+		"How long to wait for a restarted node to report the target version, in seconds. "+
+			"The binary is rolled back after that, unless another node of the host already runs the new one")
+}
+
+func (o *Options) Validate() error {
+	if err := o.RestartOptions.Validate(); err != nil {
+		return err
+	}
+
+	if o.KubeconfigPath != "" {
+		return fmt.Errorf("upgrade replaces the binary over ssh and does not support --kubeconfig")
+	}
+
+	if o.Binary == "" {
+		return fmt.Errorf("--binary unspecified, argument required")
+	}
+
+	if o.TargetVersion == "" {
+		return fmt.Errorf("--target-version unspecified, argument required")
+	}
+
+	if !safeVersionRegexp.MatchString(o.TargetVersion) {
+		return fmt.Errorf("specified invalid --target-version: %s. Must match %s", o.TargetVersion, safeVersionRegexp)
+	}
+
+	if o.VersionTimeout <= 0 {
+		return fmt.Errorf("specified invalid version timeout: %d. Must be positive", o.VersionTimeout)
+	}
+
+	if o.Checksum == "" {
+		if restarters.IsURL(o.Binary) {
+			return fmt.Errorf("--checksum unspecified, argument required when --binary is a URL")
+		}
+
+		checksum, err := fileChecksum(o.Binary)
+		if err != nil {
+			return fmt.Errorf("failed to compute the checksum of %s: %w", o.Binary, err)
+		}
+		o.Checksum = checksum
+	}
+
+	if _, err := hex.DecodeString(o.Checksum); err != nil || len(o.Checksum) != sha256.Size*2 {
+		return fmt.Errorf("specified invalid --checksum: %s. Must be a hex encoded sha256", o.Checksum)
+	}
+	o.Checksum = strings.ToLower(o.Checksum)
+
+	return nil
+}
+
+func (o *Options) Run(f cmdutil.Factory) error {
+	if err := o.ApplyPlan(); err != nil {
+		return err
+	}
+
+	storageRestarter, tenantRestarter := restarters.PrepareRestarters(
+		&o.TargetingOptions,
+		o.SSHArgs,
+		o.CustomSystemdUnitName,
+		o.TenantSystemdUnitName,
+		o.RestartDuration,
+	)
+
+	versionOf := nodeVersionGetter(f.GetCMSClient())
+	upgradeOpts := func() *restarters.UpgradeSSHOpts {
+		return &restarters.UpgradeSSHOpts{
+			Binary:              o.Binary,
+			Checksum:            o.Checksum,
+			TargetVersion:       o.TargetVersion,
+			BinaryLink:          o.BinaryLink,
+			VersionTimeout:      time.Duration(o.VersionTimeout) * time.Second,
+			VersionPollInterval: versionPollInterval,
+			VersionOf:           versionOf,
+		}
+	}
+
+	return rolling.RunRestarters(
+		f,
+		o.RestartOptions,
+		restarters.NewUpgradeSSHRestarter(options.Logger, o.SSHArgs, storageRestarter, upgradeOpts()),
+		restarters.NewUpgradeSSHRestarter(options.Logger, o.SSHArgs, tenantRestarter, upgradeOpts()),
+	)
+}
+
+func nodeVersionGetter(cmsClient cms.Client) func(*Ydb_Maintenance.Node) (string, error) {
+	return func(node *Ydb_Maintenance.Node) (string, error) {
+		nodes, err := cmsClient.Nodes()
+		if err != nil {
+			return "", err
+		}
+
+		for _, n := range nodes {
+			if n.GetNodeId() != node.GetNodeId() {
+				continue
+			}
+			if n.GetState() != Ydb_Maintenance.ItemState_ITEM_STATE_UP {
+				return "", fmt.Errorf("node %d is in state %s", n.GetNodeId(), n.GetState())
+			}
+			return n.GetVersion(), nil
+		}
+		return "", fmt.Errorf("node %d is not listed by CMS", node.GetNodeId())
+	}
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
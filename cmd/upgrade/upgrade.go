@@ -0,0 +1,51 @@
+package upgrade
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ydb-platform/ydbops/pkg/cli"
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/command"
+	"github.com/ydb-platform/ydbops/pkg/rolling"
+)
+
+var UpgradeCommandDescription = command.NewDescription(
+	"upgrade",
+	"Upgrades ydbd binary on a specified subset of nodes in the cluster",
+	`ydbops upgrade:
+  Upgrades ydbd binary on a specified subset of nodes in the cluster, one
+  CMS-approved node at a time, the same way as 'ydbops restart' does.
+
+  For every node, ydbops copies the binary over ssh (or lets the node download it),
+  verifies its checksum, atomically switches --binary-link to it, restarts the
+  systemd unit and waits until the node reports --target-version in CMS.
+  If the node fails to restart or reports another version, the link is switched
+  back to the previous binary and the node is restarted again.
+
+  The usual compatibility check between restarted and remaining nodes applies,
+  see --suppress-compat-check.`)
+
+func New(
+	f cmdutil.Factory,
+) *cobra.Command {
+	opts := &Options{
+		RestartOptions: &rolling.RestartOptions{},
+	}
+	cmd := &cobra.Command{
+		Use:     UpgradeCommandDescription.GetUse(),
+		Short:   UpgradeCommandDescription.GetShortDescription(),
+		Long:    UpgradeCommandDescription.GetLongDescription(),
+		PreRunE: cli.PopulateProfileDefaultsAndValidate(f.GetBaseOptions(), opts),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("free args not expected: %v", args)
+			}
+			return opts.Run(f)
+		},
+	}
+
+	opts.DefineFlags(cmd.Flags())
+	return cmd
+}
@@ -7,6 +7,7 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/ydb-platform/ydbops/cmd"
+	"github.com/ydb-platform/ydbops/pkg/audit"
 	"github.com/ydb-platform/ydbops/pkg/client/auth/credentials"
 	"github.com/ydb-platform/ydbops/pkg/client/cms"
 	"github.com/ydb-platform/ydbops/pkg/client/connectionsfactory"
@@ -50,8 +51,9 @@ func initClients(
 	logger *zap.SugaredLogger,
 	cp credentials.Provider,
 ) {
-	cmsClient = cms.NewCMSClient(cf, logger, cp)
 	discoveryClient = discovery.NewDiscoveryClient(cf, logger, cp)
+	auditLog := audit.New(&baseOptions.Audit, discoveryClient.WhoAmI)
+	cmsClient = cms.NewCMSClient(cf, logger, cp, auditLog)
 }
 
 func mainNoExit() error {
@@ -67,6 +69,7 @@ func mainNoExit() error {
 	initFactory()
 
 	defer func() {
+		_ = cmsClient.Close()
 		_ = logger.Sync()
 	}()
 	cmd.InitRootCommandTree(root, factory)
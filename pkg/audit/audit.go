@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/syslog"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydb-platform/ydbops/pkg/options"
+)
+
+const (
+	OperationCreateTask      = "create_task"
+	OperationCompleteActions = "complete_actions"
+	OperationDropTask        = "drop_task"
+
+	syslogTag = "ydbops"
+)
+
+// Record describes a single mutating CMS request, one JSON line per record.
+type Record struct {
+	Time        time.Time `json:"time"`
+	UserSID     string    `json:"userSid"`
+	OSUser      string    `json:"osUser,omitempty"`
+	CommandLine string    `json:"commandLine"`
+	Operation   string    `json:"operation"`
+	TaskUID     string    `json:"taskUid,omitempty"`
+	ActionUIDs  []string  `json:"actionUids,omitempty"`
+	Scopes      []string  `json:"scopes,omitempty"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Log appends records to --audit-log and, optionally, to syslog. It is
+// configured lazily, on the first record, since flags are parsed after
+// the CMS client is created.
+type Log struct {
+	opts *options.Audit
+	// whoAmI resolves the SID of the user CMS sees the requests from
+	whoAmI func() (string, error)
+
+	mu      sync.Mutex
+	userSID *string
+	syslog  *syslog.Writer
+}
+
+func New(opts *options.Audit, whoAmI func() (string, error)) *Log {
+	return &Log{
+		opts:   opts,
+		whoAmI: whoAmI,
+	}
+}
+
+func (l *Log) Enabled() bool {
+	return l != nil && l.opts.Enabled()
+}
+
+// Write fills in the time, the user and the command line and appends the record.
+func (l *Log) Write(record Record) error {
+	if !l.Enabled() {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Time = time.Now().UTC()
+	record.UserSID = l.resolveUserSID()
+	record.OSUser = osUser()
+	record.CommandLine = strings.Join(os.Args, " ")
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	var errs []error
+	if l.opts.LogFile != "" {
+		errs = append(errs, appendLine(l.opts.LogFile, line))
+	}
+	if l.opts.Syslog {
+		errs = append(errs, l.writeSyslog(line))
+	}
+	return errors.Join(errs...)
+}
+
+// Close releases the syslog connection. Nothing is kept open for the file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.syslog == nil {
+		return nil
+	}
+	err := l.syslog.Close()
+	l.syslog = nil
+	return err
+}
+
+func (l *Log) resolveUserSID() string {
+	if l.userSID == nil {
+		sid, err := l.whoAmI()
+		if err != nil {
+			// the record is still written, an unknown user is better than no record
+			sid = fmt.Sprintf("unknown (%v)", err)
+		}
+		l.userSID = &sid
+	}
+	return *l.userSID
+}
+
+func (l *Log) writeSyslog(line []byte) error {
+	if l.syslog == nil {
+		w, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTH, syslogTag)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		l.syslog = w
+	}
+	return l.syslog.Notice(string(line))
+}
+
+// appendLine opens the file for every record: O_APPEND makes each record a single
+// atomic write, even if several ydbops processes share the file.
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+func osUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
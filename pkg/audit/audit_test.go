@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ydb-platform/ydbops/pkg/options"
+)
+
+var _ = Describe("Test audit log", func() {
+	var (
+		path      string
+		whoAmIs   int
+		whoAmIErr error
+		log       *Log
+	)
+
+	readRecords := func() []Record {
+		content, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+
+		records := []Record{}
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			record := Record{}
+			Expect(json.Unmarshal([]byte(line), &record)).To(Succeed())
+			records = append(records, record)
+		}
+		return records
+	}
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "audit.jsonl")
+		whoAmIs = 0
+		whoAmIErr = nil
+		log = New(&options.Audit{LogFile: path}, func() (string, error) {
+			whoAmIs++
+			return "robot@builtin", whoAmIErr
+		})
+	})
+
+	It("appends a line per record with the user and the command line", func() {
+		Expect(os.WriteFile(path, []byte("{\"operation\":\"drop_task\"}\n"), 0o640)).To(Succeed())
+
+		Expect(log.Write(Record{
+			Operation: OperationCreateTask,
+			TaskUID:   "rolling-restart-1",
+			Scopes:    []string{"node 1", "node 2"},
+			Status:    "SUCCESS",
+		})).To(Succeed())
+		Expect(log.Write(Record{
+			Operation:  OperationCompleteActions,
+			TaskUID:    "rolling-restart-1",
+			ActionUIDs: []string{"rolling-restart-1/group-1/action-1"},
+			Status:     "SUCCESS",
+		})).To(Succeed())
+
+		records := readRecords()
+		Expect(records).To(HaveLen(3))
+		Expect(records[0].Operation).To(Equal(OperationDropTask))
+
+		Expect(records[1].Operation).To(Equal(OperationCreateTask))
+		Expect(records[1].UserSID).To(Equal("robot@builtin"))
+		Expect(records[1].CommandLine).To(Equal(strings.Join(os.Args, " ")))
+		Expect(records[1].Scopes).To(Equal([]string{"node 1", "node 2"}))
+		Expect(records[1].Time.IsZero()).To(BeFalse())
+
+		Expect(records[2].ActionUIDs).To(Equal([]string{"rolling-restart-1/group-1/action-1"}))
+
+		// the user is asked once per run
+		Expect(whoAmIs).To(Equal(1))
+	})
+
+	It("still writes the record when the user is unknown", func() {
+		whoAmIErr = errors.New("unauthenticated")
+
+		Expect(log.Write(Record{Operation: OperationDropTask, Status: "SUCCESS"})).To(Succeed())
+		Expect(readRecords()[0].UserSID).To(Equal("unknown (unauthenticated)"))
+	})
+
+	It("writes nothing when disabled", func() {
+		log = New(&options.Audit{}, func() (string, error) {
+			Fail("user must not be resolved")
+			return "", nil
+		})
+
+		Expect(log.Write(Record{Operation: OperationDropTask})).To(Succeed())
+		Expect(path).NotTo(BeAnExistingFile())
+	})
+})
@@ -28,6 +28,19 @@ func PopulateProfileDefaultsAndValidate(rootOpts *command.BaseOptions, optsArgs
 	}
 }
 
+// ValidateOptions is a lighter PreRunE for commands that must not depend on the
+// active profile, e.g. commands that manage the profile file itself.
+func ValidateOptions(optsArgs ...options.Options) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		for _, opts := range optsArgs {
+			if err := opts.Validate(); err != nil {
+				return fmt.Errorf("%w\nTry '--help' option for more info", err)
+			}
+		}
+		return nil
+	}
+}
+
 func RequireSubcommand(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("you have not selected a subcommand\nTry '--help' option for more info")
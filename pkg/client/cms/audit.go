@@ -0,0 +1,78 @@
+package cms
+
+import (
+	"fmt"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Operations"
+
+	"github.com/ydb-platform/ydbops/internal/collections"
+	"github.com/ydb-platform/ydbops/pkg/audit"
+)
+
+// auditStatusNoResponse is recorded when CMS has not responded at all,
+// so it is unknown whether the request has changed anything.
+const auditStatusNoResponse = "NO_RESPONSE"
+
+// writeAudit records a mutating request with its outcome. A failure to write
+// the record does not fail the request: it has already been made.
+func (c *defaultCMSClient) writeAudit(record audit.Record, op *Ydb_Operations.Operation, err error) {
+	if !c.auditLog.Enabled() {
+		return
+	}
+
+	record.Status = auditStatusNoResponse
+	if op != nil {
+		record.Status = op.Status.String()
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if err := c.auditLog.Write(record); err != nil {
+		c.logger.Errorf("Failed to write audit record of %s: %v", record.Operation, err)
+	}
+}
+
+func actionUIDToString(uid *Ydb_Maintenance.ActionUid) string {
+	return fmt.Sprintf("%s/%s/%s", uid.GetTaskUid(), uid.GetGroupId(), uid.GetActionId())
+}
+
+func auditActionUIDs(uids []*Ydb_Maintenance.ActionUid) []string {
+	return collections.Convert(uids, actionUIDToString)
+}
+
+// auditTaskUID is the task of the actions, they normally all belong to one.
+func auditTaskUID(uids []*Ydb_Maintenance.ActionUid) string {
+	taskUID := ""
+	for _, uid := range uids {
+		if taskUID == "" {
+			taskUID = uid.GetTaskUid()
+		} else if taskUID != uid.GetTaskUid() {
+			return ""
+		}
+	}
+	return taskUID
+}
+
+func auditScopes(ags []*Ydb_Maintenance.ActionGroup) []string {
+	scopes := []string{}
+	for _, ag := range ags {
+		for _, action := range ag.GetActions() {
+			if lock := action.GetLockAction(); lock != nil {
+				scopes = append(scopes, ScopeToString(lock.GetScope()))
+			}
+		}
+	}
+	return scopes
+}
+
+func auditCreatedActionUIDs(task MaintenanceTask) []string {
+	uids := []string{}
+	for _, gs := range task.GetActionGroupStates() {
+		for _, as := range gs.GetActionStates() {
+			uids = append(uids, actionUIDToString(as.GetActionUid()))
+		}
+	}
+	return uids
+}
@@ -15,6 +15,7 @@ import (
 	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/ydb-platform/ydbops/internal/collections"
+	"github.com/ydb-platform/ydbops/pkg/audit"
 	"github.com/ydb-platform/ydbops/pkg/client"
 	"github.com/ydb-platform/ydbops/pkg/client/auth/credentials"
 	"github.com/ydb-platform/ydbops/pkg/client/connectionsfactory"
@@ -41,17 +42,22 @@ type defaultCMSClient struct {
 	logger              *zap.SugaredLogger
 	connectionsFactory  connectionsfactory.Factory
 	credentialsProvider credentials.Provider
+	auditLog            *audit.Log
 }
 
+// NewCMSClient creates a client that records every request changing
+// maintenance tasks in auditLog, if it is not nil.
 func NewCMSClient(
 	connectionsFactory connectionsfactory.Factory,
 	logger *zap.SugaredLogger,
 	cp credentials.Provider,
+	auditLog *audit.Log,
 ) Client {
 	return &defaultCMSClient{
 		logger:              logger,
 		connectionsFactory:  connectionsFactory,
 		credentialsProvider: cp,
+		auditLog:            auditLog,
 	}
 }
 
@@ -96,17 +102,21 @@ func (c *defaultCMSClient) Nodes() ([]*Ydb_Maintenance.Node, error) {
 	return nodes, nil
 }
 
+// MaintenanceTasks lists tasks created by the user. Tasks of all users are
+// listed if userSID is empty.
 func (c *defaultCMSClient) MaintenanceTasks(userSID string) ([]MaintenanceTask, error) {
+	request := &Ydb_Maintenance.ListMaintenanceTasksRequest{
+		OperationParams: c.connectionsFactory.OperationParams(),
+	}
+	if userSID != "" {
+		request.User = &userSID
+	}
+
 	result := Ydb_Maintenance.ListMaintenanceTasksResult{}
 	c.logger.Debug("Invoke ListMaintenanceTasks method")
 	_, err := c.executeMaintenanceOperation(&result,
 		func(ctx context.Context, cl Ydb_Maintenance_V1.MaintenanceServiceClient) (client.OperationResponse, error) {
-			return cl.ListMaintenanceTasks(ctx,
-				&Ydb_Maintenance.ListMaintenanceTasksRequest{
-					OperationParams: c.connectionsFactory.OperationParams(),
-					User:            &userSID,
-				},
-			)
+			return cl.ListMaintenanceTasks(ctx, request)
 		},
 	)
 	if err != nil {
@@ -134,6 +144,9 @@ func (c *defaultCMSClient) GetMaintenanceTask(taskID string) (MaintenanceTask, e
 	return &maintenanceTaskResult{
 		TaskUID:           taskID,
 		ActionGroupStates: result.ActionGroupStates,
+		TaskOptions:       result.TaskOptions,
+		CreateTime:        result.CreateTime,
+		LastRefreshTime:   result.LastRefreshTime,
 	}, nil
 }
 
@@ -141,31 +154,56 @@ func wrapSingleScopeInActionGroup(
 	scope *Ydb_Maintenance.ActionScope,
 	duration *durationpb.Duration,
 ) *Ydb_Maintenance.ActionGroup {
-	return &Ydb_Maintenance.ActionGroup{
-		Actions: []*Ydb_Maintenance.Action{
-			{
-				Action: &Ydb_Maintenance.Action_LockAction{
-					LockAction: &Ydb_Maintenance.LockAction{
-						Scope:    scope,
-						Duration: duration,
-					},
+	return wrapScopesInActionGroup([]*Ydb_Maintenance.ActionScope{scope}, duration)
+}
+
+func wrapScopesInActionGroup(
+	scopes []*Ydb_Maintenance.ActionScope,
+	duration *durationpb.Duration,
+) *Ydb_Maintenance.ActionGroup {
+	ag := &Ydb_Maintenance.ActionGroup{
+		Actions: make([]*Ydb_Maintenance.Action, 0, len(scopes)),
+	}
+	for _, scope := range scopes {
+		ag.Actions = append(ag.Actions, &Ydb_Maintenance.Action{
+			Action: &Ydb_Maintenance.Action_LockAction{
+				LockAction: &Ydb_Maintenance.LockAction{
+					Scope:    scope,
+					Duration: duration,
 				},
 			},
-		},
+		})
 	}
+	return ag
 }
 
 func actionGroupsFromNodes(params MaintenanceTaskParams) []*Ydb_Maintenance.ActionGroup {
 	ags := make([]*Ydb_Maintenance.ActionGroup, 0, len(params.Nodes))
 
-	for _, node := range params.Nodes {
+	// groups keep the order in which their first node was specified
+	groupKeys := []string{}
+	groupScopes := make(map[string][]*Ydb_Maintenance.ActionScope)
+
+	for i, node := range params.Nodes {
 		scope := &Ydb_Maintenance.ActionScope{
 			Scope: &Ydb_Maintenance.ActionScope_NodeId{
 				NodeId: node.NodeId,
 			},
 		}
 
-		ags = append(ags, wrapSingleScopeInActionGroup(scope, params.Duration))
+		key := fmt.Sprintf("%d", i)
+		if params.GroupKey != nil {
+			key = params.GroupKey(node)
+		}
+
+		if _, present := groupScopes[key]; !present {
+			groupKeys = append(groupKeys, key)
+		}
+		groupScopes[key] = append(groupScopes[key], scope)
+	}
+
+	for _, key := range groupKeys {
+		ags = append(ags, wrapScopesInActionGroup(groupScopes[key], params.Duration))
 	}
 
 	return ags
@@ -187,6 +225,16 @@ func actionGroupsFromHosts(params MaintenanceTaskParams) []*Ydb_Maintenance.Acti
 	return ags
 }
 
+func actionGroupsFromPDisks(params MaintenanceTaskParams) []*Ydb_Maintenance.ActionGroup {
+	ags := make([]*Ydb_Maintenance.ActionGroup, 0, len(params.PDisks))
+
+	for _, pdisk := range params.PDisks {
+		ags = append(ags, wrapSingleScopeInActionGroup(pdisk.scope(), params.Duration))
+	}
+
+	return ags
+}
+
 func (c *defaultCMSClient) CreateMaintenanceTask(params MaintenanceTaskParams) (MaintenanceTask, error) {
 	request := &Ydb_Maintenance.CreateMaintenanceTaskRequest{
 		OperationParams: c.connectionsFactory.OperationParams(),
@@ -198,20 +246,28 @@ func (c *defaultCMSClient) CreateMaintenanceTask(params MaintenanceTaskParams) (
 		},
 	}
 
-	fmt.Println(params.Duration)
-	if params.ScopeType == NodeScope {
+	switch params.ScopeType {
+	case NodeScope:
 		request.ActionGroups = actionGroupsFromNodes(params)
-	} else { // HostScope
+	case PDiskScope:
+		request.ActionGroups = actionGroupsFromPDisks(params)
+	default: // HostScope
 		request.ActionGroups = actionGroupsFromHosts(params)
 	}
 
 	result := &Ydb_Maintenance.MaintenanceTaskResult{}
 	c.logger.Debug("Invoke CreateMaintenanceTask method")
-	_, err := c.executeMaintenanceOperation(result,
+	op, err := c.executeMaintenanceOperation(result,
 		func(ctx context.Context, cl Ydb_Maintenance_V1.MaintenanceServiceClient) (client.OperationResponse, error) {
 			return cl.CreateMaintenanceTask(ctx, request)
 		},
 	)
+	c.writeAudit(audit.Record{
+		Operation:  audit.OperationCreateTask,
+		TaskUID:    params.TaskUID,
+		ActionUIDs: auditCreatedActionUIDs(result),
+		Scopes:     auditScopes(request.ActionGroups),
+	}, op, err)
 	if err != nil {
 		return result, err
 	}
@@ -246,6 +302,10 @@ func (c *defaultCMSClient) DropMaintenanceTask(taskID string) (string, error) {
 			})
 		},
 	)
+	c.writeAudit(audit.Record{
+		Operation: audit.OperationDropTask,
+		TaskUID:   taskID,
+	}, op, err)
 	if err != nil {
 		return "", err
 	}
@@ -256,7 +316,7 @@ func (c *defaultCMSClient) DropMaintenanceTask(taskID string) (string, error) {
 func (c *defaultCMSClient) CompleteAction(actionIds []*Ydb_Maintenance.ActionUid) (*Ydb_Maintenance.ManageActionResult, error) {
 	result := Ydb_Maintenance.ManageActionResult{}
 	c.logger.Debug("Invoke CompleteAction method")
-	_, err := c.executeMaintenanceOperation(&result,
+	op, err := c.executeMaintenanceOperation(&result,
 		func(ctx context.Context, cl Ydb_Maintenance_V1.MaintenanceServiceClient) (client.OperationResponse, error) {
 			return cl.CompleteAction(ctx, &Ydb_Maintenance.CompleteActionRequest{
 				OperationParams: c.connectionsFactory.OperationParams(),
@@ -264,6 +324,11 @@ func (c *defaultCMSClient) CompleteAction(actionIds []*Ydb_Maintenance.ActionUid
 			})
 		},
 	)
+	c.writeAudit(audit.Record{
+		Operation:  audit.OperationCompleteActions,
+		TaskUID:    auditTaskUID(actionIds),
+		ActionUIDs: auditActionUIDs(actionIds),
+	}, op, err)
 	if err != nil {
 		return nil, err
 	}
@@ -304,12 +369,12 @@ func (c *defaultCMSClient) executeMaintenanceOperation(
 		return op, nil
 	}
 
-	if err := op.Result.UnmarshalTo(out); err != nil {
-		return op, err
+	if op.Status != Ydb.StatusIds_SUCCESS {
+		return op, &StatusError{Status: op.Status}
 	}
 
-	if op.Status != Ydb.StatusIds_SUCCESS {
-		return op, fmt.Errorf("unsuccessful status code: %s", op.Status)
+	if err := op.Result.UnmarshalTo(out); err != nil {
+		return op, err
 	}
 
 	return op, nil
@@ -346,17 +411,17 @@ func (c *defaultCMSClient) executeCMSOperation(
 		return op, nil
 	}
 
-	if err := op.Result.UnmarshalTo(out); err != nil {
-		return op, err
+	if op.Status != Ydb.StatusIds_SUCCESS {
+		return op, &StatusError{Status: op.Status}
 	}
 
-	if op.Status != Ydb.StatusIds_SUCCESS {
-		return op, fmt.Errorf("unsuccessful status code: %s", op.Status)
+	if err := op.Result.UnmarshalTo(out); err != nil {
+		return op, err
 	}
 
 	return op, nil
 }
 
 func (c *defaultCMSClient) Close() error {
-	return nil
+	return c.auditLog.Close()
 }
@@ -0,0 +1,42 @@
+package cms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusError is returned when CMS has answered with an unsuccessful status.
+type StatusError struct {
+	Status Ydb.StatusIds_StatusCode
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unsuccessful status code: %s", e.Status)
+}
+
+// IsPermanentError tells if asking CMS again will not help, e.g. the task
+// does not exist or the request is not allowed. Connection failures and
+// statuses like UNAVAILABLE or OVERLOADED are not permanent.
+func IsPermanentError(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.Status {
+		case Ydb.StatusIds_NOT_FOUND, Ydb.StatusIds_BAD_REQUEST, Ydb.StatusIds_UNAUTHORIZED,
+			Ydb.StatusIds_SCHEME_ERROR, Ydb.StatusIds_PRECONDITION_FAILED, Ydb.StatusIds_UNSUPPORTED:
+			return true
+		}
+		return false
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.NotFound, codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated, codes.Unimplemented:
+			return true
+		}
+	}
+	return false
+}
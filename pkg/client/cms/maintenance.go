@@ -1,13 +1,10 @@
 package cms
 
 import (
-	"context"
 	"fmt"
 
-	"github.com/ydb-platform/ydb-go-genproto/draft/Ydb_Maintenance_V1"
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 
-	"github.com/ydb-platform/ydbops/pkg/client"
 	"github.com/ydb-platform/ydbops/pkg/utils"
 )
 
@@ -55,28 +52,33 @@ func (d *defaultCMSClient) CompleteActions(taskID string, hosts []string) (*Ydb_
 	hostFQDNToActionUID := make(map[string]*Ydb_Maintenance.ActionUid)
 	nodeIDToActionUID := make(map[uint32]*Ydb_Maintenance.ActionUid)
 	for _, gs := range task.GetActionGroupStates() {
-		as := gs.ActionStates[0]
-		lock := as.Action.GetLockAction()
-		if lock == nil {
-			return nil, fmt.Errorf(
-				"failed to complete action: unexpected non-lock action type: %+v. Contact the developers",
-				as.Action,
-			)
-		}
-		scope := lock.Scope
-
-		hostFqdn := scope.GetHost()
-		nodeID := scope.GetNodeId()
-		switch {
-		case hostFqdn != "":
-			hostFQDNToActionUID[hostFqdn] = as.ActionUid
-		case nodeID != 0:
-			nodeIDToActionUID[nodeID] = as.ActionUid
-		default:
-			return nil, fmt.Errorf(
-				"failed to complete action. An action's scope didn't contain host or nodeID: %+v. Contact the developers",
-				scope,
-			)
+		for _, as := range gs.GetActionStates() {
+			lock := as.Action.GetLockAction()
+			if lock == nil {
+				return nil, fmt.Errorf(
+					"failed to complete action: unexpected non-lock action type: %+v. Contact the developers",
+					as.Action,
+				)
+			}
+			scope := lock.Scope
+			if scope.GetPdisk() != nil {
+				// pdisks are not addressable by hosts, see `maintenance complete --pdisks`
+				continue
+			}
+
+			hostFqdn := scope.GetHost()
+			nodeID := scope.GetNodeId()
+			switch {
+			case hostFqdn != "":
+				hostFQDNToActionUID[hostFqdn] = as.ActionUid
+			case nodeID != 0:
+				nodeIDToActionUID[nodeID] = as.ActionUid
+			default:
+				return nil, fmt.Errorf(
+					"failed to complete action. An action's scope didn't contain host or nodeID: %+v. Contact the developers",
+					scope,
+				)
+			}
 		}
 	}
 
@@ -147,16 +149,77 @@ func (d *defaultCMSClient) ListTasksForUser(userSID string) ([]MaintenanceTask,
 
 // RefreshTask implements Client.
 func (d *defaultCMSClient) RefreshTask(taskID string) (MaintenanceTask, error) {
-	var result Ydb_Maintenance.MaintenanceTaskResult
-	_, err := d.executeMaintenanceOperation(&result, func(ctx context.Context, cl Ydb_Maintenance_V1.MaintenanceServiceClient) (client.OperationResponse, error) {
-		return cl.RefreshMaintenanceTask(ctx, &Ydb_Maintenance.RefreshMaintenanceTaskRequest{
-			OperationParams: d.connectionsFactory.OperationParams(),
-			TaskUid:         taskID,
+	return d.RefreshMaintenanceTask(taskID)
+}
+
+// LockActions returns the lock actions of the task.
+func LockActions(task MaintenanceTask) []*Ydb_Maintenance.ActionState {
+	locks := []*Ydb_Maintenance.ActionState{}
+	for _, gs := range task.GetActionGroupStates() {
+		for _, as := range gs.GetActionStates() {
+			if as.GetAction().GetLockAction() != nil {
+				locks = append(locks, as)
+			}
+		}
+	}
+	return locks
+}
+
+// SelectByHosts returns the lock actions on the hosts given as node ids or host FQDNs,
+// in the order of the hosts, and the hosts none of the actions is scoped to.
+func SelectByHosts(
+	actions []*Ydb_Maintenance.ActionState,
+	hosts []string,
+) ([]*Ydb_Maintenance.ActionState, []string, error) {
+	nodeIDs, errIds := utils.GetNodeIds(hosts)
+	hostFQDNs, errFqdns := utils.GetNodeFQDNs(hosts)
+	if errIds != nil && errFqdns != nil {
+		return nil, nil, fmt.Errorf(
+			"failed to parse --hosts argument as node ids (%w) or host fqdns (%w)",
+			errIds,
+			errFqdns,
+		)
+	}
+
+	if errIds == nil {
+		selected, missing := SelectByScope(actions, nodeIDs, func(scope *Ydb_Maintenance.ActionScope) uint32 {
+			return scope.GetNodeId()
 		})
+		missingHosts := make([]string, 0, len(missing))
+		for _, nodeID := range missing {
+			missingHosts = append(missingHosts, fmt.Sprint(nodeID))
+		}
+		return selected, missingHosts, nil
+	}
+
+	selected, missing := SelectByScope(actions, hostFQDNs, func(scope *Ydb_Maintenance.ActionScope) string {
+		return scope.GetHost()
 	})
-	if err != nil {
-		return nil, err
+	return selected, missing, nil
+}
+
+// SelectByScope returns the lock actions whose scope key is one of the requested,
+// in the order of the requested keys, and the keys none of the actions has.
+func SelectByScope[T uint32 | string](
+	actions []*Ydb_Maintenance.ActionState,
+	requested []T,
+	scopeKey func(*Ydb_Maintenance.ActionScope) T,
+) ([]*Ydb_Maintenance.ActionState, []T) {
+	byKey := make(map[T]*Ydb_Maintenance.ActionState)
+	for _, as := range actions {
+		byKey[scopeKey(as.GetAction().GetLockAction().GetScope())] = as
+	}
+
+	selected := make([]*Ydb_Maintenance.ActionState, 0, len(requested))
+	missing := []T{}
+	for _, key := range requested {
+		as, ok := byKey[key]
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+		selected = append(selected, as)
 	}
 
-	return &result, nil
+	return selected, missing
 }
@@ -0,0 +1,116 @@
+package cms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+)
+
+// PDisk identifies a physical disk either by node id and pdisk id,
+// or by host FQDN and device path.
+type PDisk struct {
+	NodeID  uint32
+	PDiskID uint32
+
+	Host string
+	Path string
+}
+
+// ParsePDisks parses a list of '<node-id>:<pdisk-id>' or '<host>:<path>' specs.
+func ParsePDisks(specs []string) ([]PDisk, error) {
+	pdisks := make([]PDisk, 0, len(specs))
+	for _, spec := range specs {
+		pdisk, err := ParsePDisk(spec)
+		if err != nil {
+			return nil, err
+		}
+		pdisks = append(pdisks, pdisk)
+	}
+	return pdisks, nil
+}
+
+func ParsePDisk(spec string) (PDisk, error) {
+	left, right, found := strings.Cut(spec, ":")
+	if !found || left == "" || right == "" {
+		return PDisk{}, fmt.Errorf(
+			"invalid pdisk %q: expected <node-id>:<pdisk-id> or <host>:<path>", spec,
+		)
+	}
+
+	nodeID, errNode := strconv.ParseUint(left, 10, 32)
+	pdiskID, errPDisk := strconv.ParseUint(right, 10, 32)
+	if errNode == nil && errPDisk == nil {
+		return PDisk{NodeID: uint32(nodeID), PDiskID: uint32(pdiskID)}, nil
+	}
+
+	if !strings.HasPrefix(right, "/") {
+		return PDisk{}, fmt.Errorf(
+			"invalid pdisk %q: expected <node-id>:<pdisk-id> or <host>:<path>, path must be absolute", spec,
+		)
+	}
+	return PDisk{Host: left, Path: right}, nil
+}
+
+func (p PDisk) String() string {
+	if p.Host != "" {
+		return fmt.Sprintf("%s:%s", p.Host, p.Path)
+	}
+	return fmt.Sprintf("%d:%d", p.NodeID, p.PDiskID)
+}
+
+func (p PDisk) scope() *Ydb_Maintenance.ActionScope {
+	pdisk := &Ydb_Maintenance.ActionScope_PDisk{}
+	if p.Host != "" {
+		pdisk.Pdisk = &Ydb_Maintenance.ActionScope_PDisk_PdiskLocation{
+			PdiskLocation: &Ydb_Maintenance.ActionScope_PDiskLocation{
+				Host: p.Host,
+				Path: p.Path,
+			},
+		}
+	} else {
+		pdisk.Pdisk = &Ydb_Maintenance.ActionScope_PDisk_PdiskId{
+			PdiskId: &Ydb_Maintenance.ActionScope_PDiskId{
+				NodeId:  p.NodeID,
+				PdiskId: p.PDiskID,
+			},
+		}
+	}
+	return &Ydb_Maintenance.ActionScope{
+		Scope: &Ydb_Maintenance.ActionScope_Pdisk{Pdisk: pdisk},
+	}
+}
+
+// PDiskFromScope returns the pdisk of a pdisk-scoped action.
+func PDiskFromScope(scope *Ydb_Maintenance.ActionScope) (PDisk, bool) {
+	pdisk := scope.GetPdisk()
+	if pdisk == nil {
+		return PDisk{}, false
+	}
+	if location := pdisk.GetPdiskLocation(); location != nil {
+		return PDisk{Host: location.GetHost(), Path: location.GetPath()}, true
+	}
+	id := pdisk.GetPdiskId()
+	return PDisk{NodeID: id.GetNodeId(), PDiskID: id.GetPdiskId()}, true
+}
+
+// ScopeNodeAndHost returns what is known about the node an action scope belongs to:
+// a node id, a host FQDN, or both empty for unknown scopes.
+func ScopeNodeAndHost(scope *Ydb_Maintenance.ActionScope) (uint32, string) {
+	if pdisk, ok := PDiskFromScope(scope); ok {
+		return pdisk.NodeID, pdisk.Host
+	}
+	return scope.GetNodeId(), scope.GetHost()
+}
+
+// ScopeToString describes an action scope, e.g. 'node 1', 'host ydb-1' or 'pdisk 1:1000'.
+func ScopeToString(scope *Ydb_Maintenance.ActionScope) string {
+	if pdisk, ok := PDiskFromScope(scope); ok {
+		return "pdisk " + pdisk.String()
+	}
+	if nodeID := scope.GetNodeId(); nodeID != 0 {
+		return fmt.Sprintf("node %d", nodeID)
+	}
+	return "host " + scope.GetHost()
+}
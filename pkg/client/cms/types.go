@@ -1,6 +1,8 @@
 package cms
 
 import (
+	"time"
+
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -11,6 +13,8 @@ type MaintenanceScopeType int
 const (
 	NodeScope MaintenanceScopeType = 1
 	HostScope MaintenanceScopeType = 2
+	// PDiskScope locks single physical disks instead of whole nodes.
+	PDiskScope MaintenanceScopeType = 3
 )
 
 type MaintenanceTaskParams struct {
@@ -21,8 +25,13 @@ type MaintenanceTaskParams struct {
 
 	ScopeType MaintenanceScopeType
 
-	Nodes []*Ydb_Maintenance.Node
-	Hosts []string
+	Nodes  []*Ydb_Maintenance.Node
+	Hosts  []string
+	PDisks []PDisk
+
+	// GroupKey puts nodes with the same key into one action group, which CMS
+	// grants atomically. Only applies to NodeScope. Nil means one node per group.
+	GroupKey func(*Ydb_Maintenance.Node) string
 }
 
 type MaintenanceTask interface {
@@ -31,9 +40,21 @@ type MaintenanceTask interface {
 	GetTaskUid() string
 }
 
+// MaintenanceTaskInfo is implemented by tasks obtained with GetMaintenanceTask:
+// CMS reports them together with task options and timestamps.
+type MaintenanceTaskInfo interface {
+	MaintenanceTask
+	GetTaskOptions() *Ydb_Maintenance.MaintenanceTaskOptions
+	GetCreateTime() *timestamppb.Timestamp
+	GetLastRefreshTime() *timestamppb.Timestamp
+}
+
 type maintenanceTaskResult struct {
 	TaskUID           string
 	ActionGroupStates []*Ydb_Maintenance.ActionGroupStates
+	TaskOptions       *Ydb_Maintenance.MaintenanceTaskOptions
+	CreateTime        *timestamppb.Timestamp
+	LastRefreshTime   *timestamppb.Timestamp
 }
 
 func (g *maintenanceTaskResult) GetRetryAfter() *timestamppb.Timestamp { return nil }
@@ -41,3 +62,17 @@ func (g *maintenanceTaskResult) GetActionGroupStates() []*Ydb_Maintenance.Action
 	return g.ActionGroupStates
 }
 func (g *maintenanceTaskResult) GetTaskUid() string { return g.TaskUID }
+func (g *maintenanceTaskResult) GetTaskOptions() *Ydb_Maintenance.MaintenanceTaskOptions {
+	return g.TaskOptions
+}
+func (g *maintenanceTaskResult) GetCreateTime() *timestamppb.Timestamp      { return g.CreateTime }
+func (g *maintenanceTaskResult) GetLastRefreshTime() *timestamppb.Timestamp { return g.LastRefreshTime }
+
+// TaskCreateTime returns the time the task was created, if CMS reported it.
+func TaskCreateTime(task MaintenanceTask) (time.Time, bool) {
+	info, ok := task.(MaintenanceTaskInfo)
+	if !ok || info.GetCreateTime() == nil {
+		return time.Time{}, false
+	}
+	return info.GetCreateTime().AsTime(), true
+}
@@ -2,12 +2,15 @@ package command
 
 import (
 	"errors"
+	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/spf13/pflag"
 
+	"github.com/ydb-platform/ydbops/internal/collections"
 	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/profile"
 )
 
 type Description struct {
@@ -19,9 +22,11 @@ type Description struct {
 type BaseOptions struct {
 	Auth          options.AuthOptions
 	GRPC          options.GRPC
+	Audit         options.Audit
 	Verbose       bool
 	ProfileFile   string
 	ActiveProfile string
+	Output        string
 }
 
 func (o *BaseOptions) Validate() error {
@@ -31,6 +36,12 @@ func (o *BaseOptions) Validate() error {
 	if err := o.Auth.Validate(); err != nil {
 		return err
 	}
+	if err := o.Audit.Validate(); err != nil {
+		return err
+	}
+	if !collections.Contains(options.OutputFormats, o.Output) {
+		return fmt.Errorf("specified a non-existing output format: %s", o.Output)
+	}
 
 	return nil
 }
@@ -38,16 +49,14 @@ func (o *BaseOptions) Validate() error {
 func (o *BaseOptions) DefineFlags(fs *pflag.FlagSet) {
 	o.GRPC.DefineFlags(fs)
 	o.Auth.DefineFlags(fs)
+	o.Audit.DefineFlags(fs)
 
 	fs.StringVar(
 		&o.ActiveProfile, "profile",
 		"",
 		"Override currently set profile name from --config-file")
 
-	defaultProfileLocation := ""
-	if home, err := os.UserHomeDir(); err == nil {
-		defaultProfileLocation = filepath.Join(home, "ydb", "ydbops", "config", "config.yaml")
-	}
+	defaultProfileLocation := profile.DefaultConfigPath()
 
 	_, err := os.Stat(defaultProfileLocation)
 	if errors.Is(err, os.ErrNotExist) {
@@ -61,6 +70,9 @@ func (o *BaseOptions) DefineFlags(fs *pflag.FlagSet) {
 		defaultProfileLocation,
 		"Path to config file with profile data in yaml format. Default: $HOME/ydb/ydbops/config/config.yaml")
 
+	fs.StringVarP(&o.Output, "output", "o", options.OutputText,
+		fmt.Sprintf("Output format of command results. Available choices: %s", strings.Join(options.OutputFormats, ", ")))
+
 	fs.BoolVarP(&o.Verbose, "verbose", "v", false, "Switches log level from INFO to DEBUG")
 }
 
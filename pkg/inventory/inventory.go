@@ -0,0 +1,112 @@
+package inventory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+)
+
+const (
+	StorageNodeType = "storage"
+	DynamicNodeType = "dynamic"
+
+	// Storage nodes do not belong to any tenant, they are accounted
+	// under this name in the per-tenant summary.
+	NoTenant = "<storage>"
+)
+
+// NodeInfo is a flat, serialization-friendly view of what CMS reports about a node.
+type NodeInfo struct {
+	NodeID        uint32     `json:"nodeId" yaml:"nodeId"`
+	Host          string     `json:"host" yaml:"host"`
+	Port          uint32     `json:"port" yaml:"port"`
+	DataCenter    string     `json:"dataCenter" yaml:"dataCenter"`
+	Rack          string     `json:"rack" yaml:"rack"`
+	Type          string     `json:"type" yaml:"type"`
+	Tenant        string     `json:"tenant" yaml:"tenant"`
+	State         string     `json:"state" yaml:"state"`
+	Version       string     `json:"version" yaml:"version"`
+	StartTime     *time.Time `json:"startTime,omitempty" yaml:"startTime,omitempty"`
+	UptimeSeconds int64      `json:"uptimeSeconds,omitempty" yaml:"uptimeSeconds,omitempty"`
+}
+
+type Summary struct {
+	Total        int            `json:"total" yaml:"total"`
+	ByVersion    map[string]int `json:"byVersion" yaml:"byVersion"`
+	ByDataCenter map[string]int `json:"byDataCenter" yaml:"byDataCenter"`
+	ByTenant     map[string]int `json:"byTenant" yaml:"byTenant"`
+}
+
+func FromNode(node *Ydb_Maintenance.Node, now time.Time) NodeInfo {
+	info := NodeInfo{
+		NodeID:     node.GetNodeId(),
+		Host:       node.GetHost(),
+		Port:       node.GetPort(),
+		DataCenter: node.GetLocation().GetDataCenter(),
+		Rack:       node.GetLocation().GetRack(),
+		Type:       StorageNodeType,
+		State:      StateName(node.GetState()),
+		Version:    node.GetVersion(),
+	}
+
+	if dynamic := node.GetDynamic(); dynamic != nil {
+		info.Type = DynamicNodeType
+		info.Tenant = dynamic.GetTenant()
+	}
+
+	if node.GetStartTime() != nil {
+		startTime := node.GetStartTime().AsTime()
+		if !startTime.IsZero() && startTime.Unix() != 0 {
+			info.StartTime = &startTime
+			info.UptimeSeconds = int64(now.Sub(startTime).Seconds())
+		}
+	}
+
+	return info
+}
+
+func FromNodes(nodes []*Ydb_Maintenance.Node, now time.Time) []NodeInfo {
+	infos := make([]NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		infos = append(infos, FromNode(node, now))
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].NodeID < infos[j].NodeID
+	})
+
+	return infos
+}
+
+// StateName turns ITEM_STATE_UP into UP.
+func StateName(state Ydb_Maintenance.ItemState) string {
+	return strings.TrimPrefix(state.String(), "ITEM_STATE_")
+}
+
+func Summarize(infos []NodeInfo) Summary {
+	summary := Summary{
+		Total:        len(infos),
+		ByVersion:    make(map[string]int),
+		ByDataCenter: make(map[string]int),
+		ByTenant:     make(map[string]int),
+	}
+
+	for _, info := range infos {
+		summary.ByVersion[info.Version]++
+		summary.ByDataCenter[info.DataCenter]++
+
+		tenant := info.Tenant
+		if info.Type == StorageNodeType {
+			tenant = NoTenant
+		}
+		summary.ByTenant[tenant]++
+	}
+
+	return summary
+}
+
+func (info NodeInfo) Uptime() time.Duration {
+	return time.Duration(info.UptimeSeconds) * time.Second
+}
@@ -0,0 +1,247 @@
+package lease
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
+	"github.com/ydb-platform/ydbops/pkg/prettyprint"
+)
+
+// The Maintenance API has no way to prolong a lock that CMS has already granted,
+// and CMS does not grant a lock held by another task. Shortly before the deadlines
+// the Keeper queues the same scopes in a replacement task and drops the original
+// task right away, so that CMS passes the locks on to the replacement. From then
+// on the replacement task is the one being kept alive. If CMS grants a lock to
+// another task in between, the replacement waits for it like any other task.
+
+type Options struct {
+	// Duration of the renewed locks.
+	Duration time.Duration
+	// Renew locks when less than RenewBefore is left until their deadline.
+	RenewBefore      time.Duration
+	AvailabilityMode Ydb_Maintenance.AvailabilityMode
+	Priority         int32
+}
+
+type Keeper struct {
+	cms    cms.Client
+	logger *zap.SugaredLogger
+	opts   Options
+
+	taskID string
+}
+
+func NewKeeper(cmsClient cms.Client, logger *zap.SugaredLogger, taskID string, opts Options) *Keeper {
+	return &Keeper{
+		cms:    cmsClient,
+		logger: logger,
+		opts:   opts,
+		taskID: taskID,
+	}
+}
+
+// TaskID returns the id of the task currently holding the locks. It changes
+// every time the locks are renewed.
+func (k *Keeper) TaskID() string {
+	return k.taskID
+}
+
+// Tick refreshes the kept task, renews locks that are about to expire and
+// returns the up-to-date state of the task currently holding the locks.
+func (k *Keeper) Tick() (cms.MaintenanceTask, error) {
+	task, err := k.cms.RefreshMaintenanceTask(k.taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh maintenance task %s: %w", k.taskID, err)
+	}
+
+	now := time.Now()
+	k.warnAboutExpiredLocks(task, now)
+	if !needsRenewal(task, now, k.opts.RenewBefore) {
+		return task, nil
+	}
+
+	replacement, err := k.createReplacement(task)
+	if err != nil {
+		return task, err
+	}
+	k.logger.Infof("Requested lock extension for task %s with replacement task %s", k.taskID, replacement.GetTaskUid())
+
+	if err = k.cms.DropTask(k.taskID); err != nil {
+		if dropErr := k.cms.DropTask(replacement.GetTaskUid()); dropErr != nil {
+			k.logger.Warnf("Failed to drop replacement task %s: %v", replacement.GetTaskUid(), dropErr)
+		}
+		return task, fmt.Errorf("failed to drop task %s to pass its locks to replacement task %s: %w",
+			k.taskID, replacement.GetTaskUid(), err)
+	}
+
+	original := k.taskID
+	k.taskID = replacement.GetTaskUid()
+
+	replacement, err = k.cms.RefreshMaintenanceTask(k.taskID)
+	if err != nil {
+		return nil, fmt.Errorf("task %s replaced %s, but failed to refresh it: %w", k.taskID, original, err)
+	}
+
+	if k.replacementGranted(task, replacement) {
+		k.logger.Infof("Locks extended: task %s replaced %s, use the new task id from now on", k.taskID, original)
+	} else {
+		k.logger.Warnf("Task %s replaced %s, but CMS has not granted all of its locks yet, use the new task id from now on",
+			k.taskID, original)
+	}
+	return replacement, nil
+}
+
+// Release finishes the kept task: either completes or drops it.
+func (k *Keeper) Release(complete bool) error {
+	if !complete {
+		return k.cms.DropTask(k.taskID)
+	}
+
+	task, err := k.cms.GetMaintenanceTask(k.taskID)
+	if err != nil {
+		return fmt.Errorf("failed to get maintenance task %s: %w", k.taskID, err)
+	}
+
+	performed := []*Ydb_Maintenance.ActionUid{}
+	for _, as := range lockActionStates(task) {
+		if as.GetStatus() == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
+			performed = append(performed, as.GetActionUid())
+		}
+	}
+	if len(performed) == 0 {
+		return k.cms.DropTask(k.taskID)
+	}
+
+	_, err = k.cms.CompleteAction(performed)
+	return err
+}
+
+func (k *Keeper) createReplacement(task cms.MaintenanceTask) (cms.MaintenanceTask, error) {
+	params := cms.MaintenanceTaskParams{
+		TaskUID:          cms.TaskUuidPrefix + uuid.New().String(),
+		AvailabilityMode: k.opts.AvailabilityMode,
+		Priority:         k.opts.Priority,
+		Duration:         durationpb.New(k.opts.Duration),
+	}
+
+	// keep nodes that were locked together in one group
+	nodeGroups := make(map[uint32]int)
+	for i, gs := range task.GetActionGroupStates() {
+		for _, as := range gs.GetActionStates() {
+			if nodeID := as.GetAction().GetLockAction().GetScope().GetNodeId(); nodeID != 0 {
+				nodeGroups[nodeID] = i
+			}
+		}
+	}
+	params.GroupKey = func(node *Ydb_Maintenance.Node) string {
+		return strconv.Itoa(nodeGroups[node.GetNodeId()])
+	}
+
+	for _, as := range lockActionStates(task) {
+		scope := as.GetAction().GetLockAction().GetScope()
+		pdisk, isPDisk := cms.PDiskFromScope(scope)
+		switch {
+		case isPDisk && sameScopeType(params.ScopeType, cms.PDiskScope):
+			params.ScopeType = cms.PDiskScope
+			params.PDisks = append(params.PDisks, pdisk)
+		case scope.GetNodeId() != 0 && sameScopeType(params.ScopeType, cms.NodeScope):
+			params.ScopeType = cms.NodeScope
+			params.Nodes = append(params.Nodes, &Ydb_Maintenance.Node{NodeId: scope.GetNodeId()})
+		case scope.GetHost() != "" && sameScopeType(params.ScopeType, cms.HostScope):
+			params.ScopeType = cms.HostScope
+			params.Hosts = append(params.Hosts, scope.GetHost())
+		default:
+			return nil, fmt.Errorf(
+				"can not extend locks of task %s: only tasks locking either node ids, hosts or pdisks are supported, got %+v",
+				task.GetTaskUid(), scope,
+			)
+		}
+	}
+
+	replacement, err := k.cms.CreateMaintenanceTask(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replacement task for %s: %w", task.GetTaskUid(), err)
+	}
+	return replacement, nil
+}
+
+// replacementGranted reports whether every lock held by the task is held by the
+// replacement. Locks CMS has not granted again are reported as warnings.
+func (k *Keeper) replacementGranted(task, replacement cms.MaintenanceTask) bool {
+	replacementStates := make(map[string]*Ydb_Maintenance.ActionState)
+	for _, as := range lockActionStates(replacement) {
+		replacementStates[scopeKey(as)] = as
+	}
+
+	granted := true
+	for _, as := range lockActionStates(task) {
+		if as.GetStatus() != Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
+			continue
+		}
+
+		renewed, ok := replacementStates[scopeKey(as)]
+		if ok && renewed.GetStatus() == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
+			continue
+		}
+
+		granted = false
+		if ok {
+			k.logger.Warnf("CMS has not granted the lock again yet: %s", prettyprint.ActionStateToString(renewed))
+		} else {
+			k.logger.Warnf("CMS has not granted the lock again yet: %s", prettyprint.ActionStateToString(as))
+		}
+	}
+
+	return granted
+}
+
+func (k *Keeper) warnAboutExpiredLocks(task cms.MaintenanceTask, now time.Time) {
+	for _, as := range lockActionStates(task) {
+		if as.GetStatus() == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED &&
+			as.GetDeadline() != nil && as.GetDeadline().AsTime().Before(now) {
+			k.logger.Warnf(
+				"Lock has expired, CMS treats any further maintenance as a regular failure: %s",
+				prettyprint.ActionStateToString(as),
+			)
+		}
+	}
+}
+
+func needsRenewal(task cms.MaintenanceTask, now time.Time, renewBefore time.Duration) bool {
+	for _, as := range lockActionStates(task) {
+		if as.GetStatus() == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED &&
+			as.GetDeadline() != nil && as.GetDeadline().AsTime().Sub(now) < renewBefore {
+			return true
+		}
+	}
+	return false
+}
+
+func lockActionStates(task cms.MaintenanceTask) []*Ydb_Maintenance.ActionState {
+	states := []*Ydb_Maintenance.ActionState{}
+	for _, gs := range task.GetActionGroupStates() {
+		for _, as := range gs.GetActionStates() {
+			if as.GetAction().GetLockAction() != nil {
+				states = append(states, as)
+			}
+		}
+	}
+	return states
+}
+
+// sameScopeType tells if a scope of type t can be added to a task
+// that already has scopes of type current.
+func sameScopeType(current, t cms.MaintenanceScopeType) bool {
+	return current == 0 || current == t
+}
+
+func scopeKey(as *Ydb_Maintenance.ActionState) string {
+	return cms.ScopeToString(as.GetAction().GetLockAction().GetScope())
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"time"
+)
+
+const (
+	EventStarted           = "started"
+	EventNodeFailed        = "node_failed"
+	EventBudgetExceeded    = "failure_budget_exceeded"
+	EventCompatCheckFailed = "compat_check_failed"
+	EventFinished          = "finished"
+)
+
+// Event is a milestone of a rolling operation. It is the data templates
+// are executed on, and the default webhook payload.
+type Event struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	TaskUID string    `json:"taskUid,omitempty"`
+	NodeID  uint32    `json:"nodeId,omitempty"`
+	Error   string    `json:"error,omitempty"`
+
+	// FailedNodes and MaxFailedNodes are set when the failure budget trips
+	FailedNodes    int `json:"failedNodes,omitempty"`
+	MaxFailedNodes int `json:"maxFailedNodes,omitempty"`
+}
+
+// Notifier delivers events somewhere outside ydbops. Notify must not block
+// the rolling operation for long and never fails it, Close flushes events
+// that are not delivered yet.
+type Notifier interface {
+	Notify(event Event)
+	Close()
+}
+
+// Nop is the notifier used when no notifications are configured.
+type Nop struct{}
+
+func (Nop) Notify(Event) {}
+
+func (Nop) Close() {}
+
+// NewEvent fills in the time of the event.
+func NewEvent(eventType, message string) Event {
+	return Event{
+		Type:    eventType,
+		Time:    time.Now().UTC(),
+		Message: message,
+	}
+}
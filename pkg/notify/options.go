@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+const (
+	DefaultRetries            = 3
+	DefaultMinIntervalSeconds = 1
+	webhookRetryInterval      = 5 * time.Second
+	webhookTimeout            = 10 * time.Second
+)
+
+// Options configure lifecycle notifications of rolling operations.
+type Options struct {
+	WebhookURL   string
+	TemplateFile string
+	Retries      int
+	MinInterval  int
+
+	template string
+
+	once     sync.Once
+	notifier Notifier
+}
+
+func (o *Options) DefineFlags(fs *pflag.FlagSet) {
+	profile.PopulateFromProfileLater(
+		fs.StringVar, &o.WebhookURL, "notify-webhook",
+		"",
+		`[can specify in profile] URL to POST notifications to when the operation starts, a node fails
+all its attempts, too many nodes have failed, the compatibility check fires and the operation finishes`)
+
+	profile.PopulateFromProfileLater(
+		fs.StringVar, &o.TemplateFile, "notify-template",
+		"",
+		`[can specify in profile] File with a Go text/template for the notification body, executed on
+an event with .Type, .Time, .Message, .TaskUID, .NodeID, .Error, .FailedNodes and .MaxFailedNodes fields. 'json' quotes a value,
+e.g. '{"text": {{json .Message}}}'. By default, the event itself is posted as JSON`)
+
+	fs.IntVar(&o.Retries, "notify-retries", DefaultRetries,
+		"How many times to retry a notification the webhook has not accepted")
+
+	fs.IntVar(&o.MinInterval, "notify-min-interval", DefaultMinIntervalSeconds,
+		"Minimum time between two notifications, in seconds. Notifications are delayed, not dropped")
+}
+
+func (o *Options) Validate() error {
+	if o.WebhookURL == "" {
+		if o.TemplateFile != "" {
+			return fmt.Errorf("--notify-template specified, but --notify-webhook is not")
+		}
+		return nil
+	}
+
+	u, err := url.Parse(o.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("specified invalid --notify-webhook: %s. Must be an http(s) URL", o.WebhookURL)
+	}
+
+	if o.Retries < 0 {
+		return fmt.Errorf("specified invalid notify retries: %d. Must not be negative", o.Retries)
+	}
+
+	if o.MinInterval < 0 {
+		return fmt.Errorf("specified invalid notify min interval: %d. Must not be negative", o.MinInterval)
+	}
+
+	if o.TemplateFile != "" {
+		content, err := os.ReadFile(o.TemplateFile)
+		if err != nil {
+			return fmt.Errorf("failed to read --notify-template: %w", err)
+		}
+		o.template = string(content)
+
+		// parse the template right away, not when the first event comes
+		if _, err = parseTemplate(o.template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Notifier returns the configured notifier, it is shared by everything
+// running with these options. The webhook and its sender are only started
+// by the first call, so whoever calls Notifier must Close it.
+func (o *Options) Notifier() Notifier {
+	o.once.Do(func() {
+		o.notifier = Nop{}
+		if o.WebhookURL == "" {
+			return
+		}
+
+		webhook, err := NewWebhook(options.Logger, o.webhookOptions())
+		if err != nil {
+			options.Logger.Errorf("Notifications are disabled: %v", err)
+			return
+		}
+		o.notifier = webhook
+	})
+	return o.notifier
+}
+
+func (o *Options) webhookOptions() WebhookOptions {
+	return WebhookOptions{
+		URL:           o.WebhookURL,
+		Template:      o.template,
+		Retries:       o.Retries,
+		RetryInterval: webhookRetryInterval,
+		MinInterval:   time.Duration(o.MinInterval) * time.Second,
+		Timeout:       webhookTimeout,
+	}
+}
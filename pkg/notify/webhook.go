@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// queued events beyond this are dropped instead of blocking the rolling operation
+const webhookQueueSize = 100
+
+type WebhookOptions struct {
+	URL string
+	// Template is a text/template executed on an Event to get the request body.
+	// Empty means the event as JSON
+	Template string
+
+	Retries       int
+	RetryInterval time.Duration
+	// MinInterval is the minimum time between two requests
+	MinInterval time.Duration
+	Timeout     time.Duration
+}
+
+// Webhook posts events to an http endpoint, one at a time, in the background.
+type Webhook struct {
+	logger   *zap.SugaredLogger
+	opts     WebhookOptions
+	template *template.Template
+	client   *http.Client
+
+	queue chan Event
+	done  chan struct{}
+}
+
+func NewWebhook(logger *zap.SugaredLogger, opts WebhookOptions) (*Webhook, error) {
+	w := &Webhook{
+		logger: logger,
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		queue:  make(chan Event, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+
+	if opts.Template != "" {
+		t, err := parseTemplate(opts.Template)
+		if err != nil {
+			return nil, err
+		}
+		w.template = t
+	}
+
+	go w.run()
+	return w, nil
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("webhook").Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook template: %w", err)
+	}
+	return t, nil
+}
+
+func (w *Webhook) Notify(event Event) {
+	select {
+	case w.queue <- event:
+	default:
+		w.logger.Warnf("Too many undelivered notifications, dropping %s event", event.Type)
+	}
+}
+
+func (w *Webhook) Close() {
+	close(w.queue)
+	<-w.done
+}
+
+func (w *Webhook) run() {
+	defer close(w.done)
+
+	var lastSent time.Time
+	for event := range w.queue {
+		if wait := w.opts.MinInterval - time.Since(lastSent); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		if err := w.deliver(event); err != nil {
+			w.logger.Warnf("Failed to deliver %s notification: %v", event.Type, err)
+		}
+		lastSent = time.Now()
+	}
+}
+
+func (w *Webhook) deliver(event Event) error {
+	body, err := w.render(event)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = w.post(body)
+		if err == nil || attempt >= w.opts.Retries {
+			return err
+		}
+		w.logger.Debugf("Failed to post %s notification, attempt %d: %v", event.Type, attempt+1, err)
+		time.Sleep(w.opts.RetryInterval)
+	}
+}
+
+func (w *Webhook) render(event Event) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(event)
+	}
+
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to execute webhook template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *Webhook) post(body []byte) error {
+	resp, err := w.client.Post(w.opts.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// toJSON lets templates embed arbitrary strings into JSON payloads,
+// e.g. {"text": {{json .Message}}}
+func toJSON(v any) (string, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
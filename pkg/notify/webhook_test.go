@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Test webhook notifier", func() {
+	var (
+		server   *httptest.Server
+		mu       sync.Mutex
+		bodies   []string
+		requests []time.Time
+		failures int
+	)
+
+	BeforeEach(func() {
+		bodies = nil
+		requests = nil
+		failures = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			mu.Lock()
+			defer mu.Unlock()
+			requests = append(requests, time.Now())
+			if failures > 0 {
+				failures--
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			bodies = append(bodies, string(body))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newWebhook := func(opts WebhookOptions) *Webhook {
+		opts.URL = server.URL
+		webhook, err := NewWebhook(zap.S(), opts)
+		Expect(err).NotTo(HaveOccurred())
+		return webhook
+	}
+
+	It("posts events as JSON by default", func() {
+		webhook := newWebhook(WebhookOptions{})
+		event := NewEvent(EventNodeFailed, "Gave up restarting node 1")
+		event.NodeID = 1
+		webhook.Notify(event)
+		webhook.Close()
+
+		Expect(bodies).To(HaveLen(1))
+		var received Event
+		Expect(json.Unmarshal([]byte(bodies[0]), &received)).To(Succeed())
+		Expect(received.Type).To(Equal(EventNodeFailed))
+		Expect(received.NodeID).To(Equal(uint32(1)))
+	})
+
+	It("renders the template", func() {
+		webhook := newWebhook(WebhookOptions{Template: `{"text": {{json .Message}}}`})
+		webhook.Notify(NewEvent(EventFinished, `Rolling restart "done"`))
+		webhook.Close()
+
+		Expect(bodies).To(Equal([]string{`{"text": "Rolling restart \"done\""}`}))
+	})
+
+	It("retries events the webhook has not accepted", func() {
+		failures = 2
+		webhook := newWebhook(WebhookOptions{Retries: 2})
+		webhook.Notify(NewEvent(EventStarted, "Rolling restart started"))
+		webhook.Close()
+
+		Expect(requests).To(HaveLen(3))
+		Expect(bodies).To(HaveLen(1))
+	})
+
+	It("gives up after the retries", func() {
+		failures = 2
+		webhook := newWebhook(WebhookOptions{Retries: 1})
+		webhook.Notify(NewEvent(EventStarted, "Rolling restart started"))
+		webhook.Close()
+
+		Expect(requests).To(HaveLen(2))
+		Expect(bodies).To(BeEmpty())
+	})
+
+	It("keeps the minimum interval between requests", func() {
+		webhook := newWebhook(WebhookOptions{MinInterval: 100 * time.Millisecond})
+		webhook.Notify(NewEvent(EventStarted, "Rolling restart started"))
+		webhook.Notify(NewEvent(EventFinished, "Rolling restart completed successfully"))
+		webhook.Close()
+
+		Expect(requests).To(HaveLen(2))
+		Expect(requests[1].Sub(requests[0])).To(BeNumerically(">=", 100*time.Millisecond))
+	})
+
+	It("rejects a broken template", func() {
+		_, err := NewWebhook(zap.S(), WebhookOptions{URL: server.URL, Template: "{{.Message"})
+		Expect(err).To(MatchError(ContainSubstring("failed to parse webhook template")))
+	})
+})
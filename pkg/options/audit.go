@@ -0,0 +1,54 @@
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/ydb-platform/ydbops/pkg/profile"
+)
+
+// Audit configures the log of CMS mutations, see pkg/audit.
+type Audit struct {
+	LogFile string
+	Syslog  bool
+}
+
+func (o *Audit) DefineFlags(fs *pflag.FlagSet) {
+	profile.PopulateFromProfileLater(
+		fs.StringVar, &o.LogFile, "audit-log",
+		"",
+		`[can specify in profile] Append a JSON line to this file for every maintenance task
+ydbops creates, completes or drops. Refreshes are not recorded`)
+
+	fs.BoolVar(&o.Syslog, "audit-syslog", false,
+		"Also send audit records to the local syslog")
+}
+
+func (o *Audit) Validate() error {
+	if o.LogFile == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(o.LogFile, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to expand ~ in --audit-log: %w", err)
+		}
+		o.LogFile = filepath.Join(home, o.LogFile[2:])
+	}
+
+	// fail before anything is changed in CMS, not after
+	f, err := os.OpenFile(o.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open --audit-log: %w", err)
+	}
+	return f.Close()
+}
+
+func (o *Audit) Enabled() bool {
+	return o.LogFile != "" || o.Syslog
+}
@@ -0,0 +1,27 @@
+package options
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test audit options", func() {
+	It("expands ~ in the audit log path to the home directory", func() {
+		home := GinkgoT().TempDir()
+		GinkgoT().Setenv("HOME", home)
+
+		o := Audit{LogFile: "~/audit.log"}
+		Expect(o.Validate()).To(Succeed())
+		Expect(o.LogFile).To(Equal(filepath.Join(home, "audit.log")))
+		Expect(o.LogFile).To(BeARegularFile())
+	})
+
+	It("fails when the home directory is unknown", func() {
+		GinkgoT().Setenv("HOME", "")
+
+		o := Audit{LogFile: "~/audit.log"}
+		Expect(o.Validate()).To(MatchError(ContainSubstring("failed to expand ~ in --audit-log")))
+	})
+})
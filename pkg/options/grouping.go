@@ -0,0 +1,128 @@
+package options
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ydb-platform/ydbops/internal/collections"
+)
+
+const (
+	GroupByNone       = "none"
+	GroupByHost       = "host"
+	GroupByRack       = "rack"
+	GroupByDatacenter = "dc"
+	GroupByCustom     = "custom"
+)
+
+var GroupByChoices = []string{GroupByNone, GroupByHost, GroupByRack, GroupByDatacenter, GroupByCustom}
+
+// GroupingOptions control how nodes are put into CMS action groups.
+// CMS grants all actions of a group at once, or none of them.
+type GroupingOptions struct {
+	GroupBy    string
+	GroupsFile string
+
+	// customGroups maps a node id or host FQDN to the group name from --groups-file.
+	customGroups map[string]string
+}
+
+func (o *GroupingOptions) DefineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.GroupBy, "group-by", GroupByNone,
+		fmt.Sprintf(`Lock nodes in groups: all nodes of a group are taken out of the cluster together,
+or none of them. No more than --nodes-inflight nodes of a locked group are restarted at once.
+Available choices: %s.
+  'custom' requires --groups-file`, strings.Join(GroupByChoices, ", ")))
+
+	fs.StringVar(&o.GroupsFile, "groups-file", "",
+		`YAML file with custom node groups for '--group-by custom'. Maps a group name to a list
+of node ids or host FQDNs. Nodes that are not listed are locked one by one. E.g.:
+  rack-a: [ydb-1.ydb.tech, ydb-2.ydb.tech]
+  pair: [5, 6]`)
+}
+
+func (o *GroupingOptions) Validate() error {
+	if !collections.Contains(GroupByChoices, o.GroupBy) {
+		return fmt.Errorf("specified a non-existing --group-by: %s", o.GroupBy)
+	}
+
+	if o.GroupBy != GroupByCustom {
+		if o.GroupsFile != "" {
+			return fmt.Errorf("--groups-file specified, but --group-by is not 'custom'")
+		}
+		return nil
+	}
+
+	if o.GroupsFile == "" {
+		return fmt.Errorf("--group-by custom specified, but --groups-file is not")
+	}
+
+	content, err := os.ReadFile(o.GroupsFile)
+	if err != nil {
+		return fmt.Errorf("failed to read --groups-file: %w", err)
+	}
+
+	groups := map[string][]string{}
+	if err = yaml.Unmarshal(content, &groups); err != nil {
+		return fmt.Errorf("failed to parse --groups-file %s: %w", o.GroupsFile, err)
+	}
+
+	o.customGroups = make(map[string]string)
+	for group, members := range groups {
+		for _, member := range members {
+			if other, present := o.customGroups[member]; present {
+				return fmt.Errorf("%s is listed in two groups in --groups-file: %s and %s", member, other, group)
+			}
+			o.customGroups[member] = group
+		}
+	}
+
+	return nil
+}
+
+// NodeGroupKey returns a function that tells which group a node belongs to,
+// or nil if nodes should not be grouped.
+func (o *GroupingOptions) NodeGroupKey() func(*Ydb_Maintenance.Node) string {
+	ownGroup := func(node *Ydb_Maintenance.Node) string {
+		return "node-" + strconv.FormatUint(uint64(node.GetNodeId()), 10)
+	}
+
+	switch o.GroupBy {
+	case GroupByHost:
+		return func(node *Ydb_Maintenance.Node) string {
+			return "host-" + node.GetHost()
+		}
+	case GroupByRack:
+		return func(node *Ydb_Maintenance.Node) string {
+			if rack := node.GetLocation().GetRack(); rack != "" {
+				return "rack-" + rack
+			}
+			return ownGroup(node)
+		}
+	case GroupByDatacenter:
+		return func(node *Ydb_Maintenance.Node) string {
+			if dc := node.GetLocation().GetDataCenter(); dc != "" {
+				return "dc-" + dc
+			}
+			return ownGroup(node)
+		}
+	case GroupByCustom:
+		return func(node *Ydb_Maintenance.Node) string {
+			if group, present := o.customGroups[strconv.FormatUint(uint64(node.GetNodeId()), 10)]; present {
+				return "custom-" + group
+			}
+			if group, present := o.customGroups[node.GetHost()]; present {
+				return "custom-" + group
+			}
+			return ownGroup(node)
+		}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Discovery"
+)
+
+var _ = Describe("Test grouping nodes", func() {
+	rack := "rack-1"
+	node := func(nodeID uint32, host string) *Ydb_Maintenance.Node {
+		return &Ydb_Maintenance.Node{
+			NodeId:   nodeID,
+			Host:     host,
+			Location: &Ydb_Discovery.NodeLocation{Rack: &rack},
+		}
+	}
+
+	It("does not group nodes by default", func() {
+		o := GroupingOptions{GroupBy: GroupByNone}
+		Expect(o.Validate()).To(Succeed())
+		Expect(o.NodeGroupKey()).To(BeNil())
+	})
+
+	It("groups nodes by host and rack", func() {
+		o := GroupingOptions{GroupBy: GroupByHost}
+		Expect(o.Validate()).To(Succeed())
+		key := o.NodeGroupKey()
+		Expect(key(node(1, "a"))).To(Equal(key(node(2, "a"))))
+		Expect(key(node(1, "a"))).NotTo(Equal(key(node(3, "b"))))
+
+		o = GroupingOptions{GroupBy: GroupByRack}
+		key = o.NodeGroupKey()
+		Expect(key(node(1, "a"))).To(Equal(key(node(3, "b"))))
+	})
+
+	It("groups nodes from --groups-file by node id or host", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "groups.yaml")
+		Expect(os.WriteFile(path, []byte("first: [1, b]\nsecond: [c]\n"), 0o600)).To(Succeed())
+
+		o := GroupingOptions{GroupBy: GroupByCustom, GroupsFile: path}
+		Expect(o.Validate()).To(Succeed())
+		key := o.NodeGroupKey()
+		Expect(key(node(1, "a"))).To(Equal(key(node(2, "b"))))
+		Expect(key(node(1, "a"))).NotTo(Equal(key(node(3, "c"))))
+		Expect(key(node(4, "d"))).NotTo(Equal(key(node(5, "d"))))
+	})
+
+	It("rejects a node listed in two groups", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "groups.yaml")
+		Expect(os.WriteFile(path, []byte("first: [1]\nsecond: [1]\n"), 0o600)).To(Succeed())
+
+		o := GroupingOptions{GroupBy: GroupByCustom, GroupsFile: path}
+		Expect(o.Validate()).To(MatchError(ContainSubstring("listed in two groups")))
+	})
+})
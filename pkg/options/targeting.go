@@ -195,7 +195,11 @@ func parseVersionFlag(versionUnparsedFlag string) (VersionSpec, error) {
 }
 
 func (o *TargetingOptions) GetAvailabilityMode() Ydb_Maintenance.AvailabilityMode {
-	title := strings.ToUpper(fmt.Sprintf("availability_mode_%s", o.AvailabilityMode))
+	return AvailabilityModeFromString(o.AvailabilityMode)
+}
+
+func AvailabilityModeFromString(mode string) Ydb_Maintenance.AvailabilityMode {
+	title := strings.ToUpper(fmt.Sprintf("availability_mode_%s", mode))
 	value := Ydb_Maintenance.AvailabilityMode_value[title]
 
 	return Ydb_Maintenance.AvailabilityMode(value)
@@ -16,6 +16,14 @@ const (
 
 var AvailabilityModes = []string{"strong", "weak", "force", "smart"}
 
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+var OutputFormats = []string{OutputText, OutputJSON, OutputYAML}
+
 type StartedTime struct {
 	Timestamp time.Time
 	Direction rune
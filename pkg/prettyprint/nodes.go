@@ -0,0 +1,84 @@
+package prettyprint
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ydb-platform/ydbops/pkg/inventory"
+)
+
+func NodesToTable(infos []inventory.NodeInfo) string {
+	sb := strings.Builder{}
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NODE ID\tHOST\tDC\tRACK\tTYPE\tTENANT\tSTATE\tVERSION\tUPTIME")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			info.NodeID,
+			info.Host,
+			valueOrDash(info.DataCenter),
+			valueOrDash(info.Rack),
+			info.Type,
+			valueOrDash(info.Tenant),
+			info.State,
+			valueOrDash(info.Version),
+			UptimeToString(info),
+		)
+	}
+	_ = w.Flush()
+
+	return sb.String()
+}
+
+func NodesSummaryToString(summary inventory.Summary) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("Total nodes: %d\n", summary.Total))
+	writeCounts(&sb, "By version", summary.ByVersion)
+	writeCounts(&sb, "By datacenter", summary.ByDataCenter)
+	writeCounts(&sb, "By tenant", summary.ByTenant)
+	return sb.String()
+}
+
+func UptimeToString(info inventory.NodeInfo) string {
+	if info.StartTime == nil {
+		return "-"
+	}
+
+	uptime := info.Uptime()
+	days := int(uptime / (24 * time.Hour))
+	uptime -= time.Duration(days) * 24 * time.Hour
+	uptime = uptime.Truncate(time.Minute)
+
+	if days > 0 {
+		return strconv.Itoa(days) + "d" + strings.TrimSuffix(uptime.String(), "0s")
+	}
+	if uptime < time.Minute {
+		return info.Uptime().String()
+	}
+	return strings.TrimSuffix(uptime.String(), "0s")
+}
+
+func writeCounts(sb *strings.Builder, title string, counts map[string]int) {
+	sb.WriteString(title + ":\n")
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf("  %s: %d\n", valueOrDash(key), counts[key]))
+	}
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -19,26 +19,30 @@ func TaskToString(task cms.MaintenanceTask) string {
 	}
 
 	for _, gs := range task.GetActionGroupStates() {
-		as := gs.ActionStates[0]
-
-		lock := as.Action.GetLockAction()
-		if lock == nil {
-			sb.WriteString("  Non-lock action ")
-		} else if nodeId := lock.Scope.GetNodeId(); nodeId != 0 {
-			sb.WriteString(fmt.Sprintf("  Lock on node %d ", nodeId))
-		} else {
-			sb.WriteString(fmt.Sprintf("  Lock on host %s ", lock.Scope.GetHost()))
+		for _, as := range gs.GetActionStates() {
+			sb.WriteString("  " + ActionStateToString(as) + "\n")
 		}
+	}
+	return sb.String()
+}
 
-		if as.Status == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
-			sb.WriteString(fmt.Sprintf("PERFORMED, until: %s", as.Deadline.AsTime().Format(time.DateTime)))
-		} else {
-			sb.WriteString(fmt.Sprintf("%s, %s", as.Status.String(), as.GetReason().String()))
-			if details := as.GetDetails(); details != "" {
-				sb.WriteString(fmt.Sprintf(" (%s)", details))
-			}
+func ActionStateToString(as *Ydb_Maintenance.ActionState) string {
+	sb := strings.Builder{}
+
+	lock := as.Action.GetLockAction()
+	if lock == nil {
+		sb.WriteString("Non-lock action ")
+	} else {
+		sb.WriteString(fmt.Sprintf("Lock on %s ", cms.ScopeToString(lock.Scope)))
+	}
+
+	if as.Status == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
+		sb.WriteString(fmt.Sprintf("PERFORMED, until: %s", as.Deadline.AsTime().Format(time.DateTime)))
+	} else {
+		sb.WriteString(fmt.Sprintf("%s, %s", as.Status.String(), as.GetReason().String()))
+		if details := as.GetDetails(); details != "" {
+			sb.WriteString(fmt.Sprintf(" (%s)", details))
 		}
-		sb.WriteString("\n")
 	}
 	return sb.String()
 }
@@ -52,3 +56,62 @@ func ResultToString(result *Ydb_Maintenance.ManageActionResult) string {
 
 	return sb.String()
 }
+
+func LocationToString(location *Location) string {
+	if location == nil {
+		return ""
+	}
+
+	parts := []string{}
+	if location.Host != "" {
+		parts = append(parts, "host "+location.Host)
+	}
+	if location.DataCenter != "" {
+		parts = append(parts, "dc "+location.DataCenter)
+	}
+	if len(location.Tenants) > 0 {
+		parts = append(parts, "tenant "+strings.Join(location.Tenants, ", "))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return "[" + strings.Join(parts, "; ") + "]"
+}
+
+// ListedTaskToString renders a task for `maintenance list`: unlike TaskToString
+// it also shows when the task was created and where every locked scope lives.
+func ListedTaskToString(
+	task cms.MaintenanceTask,
+	locate func(nodeID uint32, host string) *Location,
+	now time.Time,
+) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("Uid: %s\n", task.GetTaskUid()))
+
+	if info, ok := task.(cms.MaintenanceTaskInfo); ok {
+		if description := info.GetTaskOptions().GetDescription(); description != "" {
+			sb.WriteString(fmt.Sprintf("Description: %s\n", description))
+		}
+		if info.GetCreateTime() != nil {
+			createTime := info.GetCreateTime().AsTime()
+			sb.WriteString(fmt.Sprintf("Created: %s (%s ago)\n",
+				createTime.Format(time.DateTime),
+				now.Sub(createTime).Truncate(time.Second),
+			))
+		}
+	}
+
+	for _, gs := range task.GetActionGroupStates() {
+		for _, as := range gs.GetActionStates() {
+			sb.WriteString("  " + ActionStateToString(as))
+			scope := as.GetAction().GetLockAction().GetScope()
+			if location := LocationToString(locate(cms.ScopeNodeAndHost(scope))); location != "" {
+				sb.WriteString(" " + location)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
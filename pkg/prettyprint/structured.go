@@ -0,0 +1,186 @@
+package prettyprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
+	"github.com/ydb-platform/ydbops/pkg/options"
+)
+
+// Task is the stable, machine-readable schema of a maintenance task,
+// printed by maintenance commands with --output json|yaml. Every status
+// and reason in the schema is a CMS enum name without the prefix of its
+// type, e.g. PERFORMED for ACTION_STATUS_PERFORMED, see enumName.
+type Task struct {
+	UID          string        `json:"uid" yaml:"uid"`
+	Description  string        `json:"description,omitempty" yaml:"description,omitempty"`
+	CreateTime   *time.Time    `json:"createTime,omitempty" yaml:"createTime,omitempty"`
+	RetryAfter   *time.Time    `json:"retryAfter,omitempty" yaml:"retryAfter,omitempty"`
+	ActionGroups []ActionGroup `json:"actionGroups" yaml:"actionGroups"`
+}
+
+type ActionGroup struct {
+	Actions []Action `json:"actions" yaml:"actions"`
+}
+
+type Action struct {
+	ActionUID ActionUID  `json:"actionUid" yaml:"actionUid"`
+	NodeID    uint32     `json:"nodeId,omitempty" yaml:"nodeId,omitempty"`
+	Host      string     `json:"host,omitempty" yaml:"host,omitempty"`
+	PDisk     string     `json:"pdisk,omitempty" yaml:"pdisk,omitempty"`
+	Status    string     `json:"status" yaml:"status"`
+	Reason    string     `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Details   string     `json:"details,omitempty" yaml:"details,omitempty"`
+	Deadline  *time.Time `json:"deadline,omitempty" yaml:"deadline,omitempty"`
+	Location  *Location  `json:"location,omitempty" yaml:"location,omitempty"`
+}
+
+// Location tells where the scope of an action lives in the cluster.
+type Location struct {
+	Host       string   `json:"host,omitempty" yaml:"host,omitempty"`
+	DataCenter string   `json:"dataCenter,omitempty" yaml:"dataCenter,omitempty"`
+	Tenants    []string `json:"tenants,omitempty" yaml:"tenants,omitempty"`
+}
+
+type ActionUID struct {
+	TaskUID  string `json:"taskUid" yaml:"taskUid"`
+	GroupID  string `json:"groupId" yaml:"groupId"`
+	ActionID string `json:"actionId" yaml:"actionId"`
+}
+
+// ActionResult is the machine-readable schema of a manage-action result,
+// e.g. the outcome of `maintenance complete`.
+type ActionResult struct {
+	ActionStatuses []ActionStatus `json:"actionStatuses" yaml:"actionStatuses"`
+}
+
+// DropResult is the machine-readable schema of `maintenance drop` output.
+type DropResult struct {
+	DroppedTaskUIDs []string `json:"droppedTaskUids" yaml:"droppedTaskUids"`
+}
+
+type ActionStatus struct {
+	ActionUID ActionUID `json:"actionUid" yaml:"actionUid"`
+	Status    string    `json:"status" yaml:"status"`
+}
+
+func TaskToStructured(task cms.MaintenanceTask) Task {
+	result := Task{
+		UID:          task.GetTaskUid(),
+		ActionGroups: make([]ActionGroup, 0, len(task.GetActionGroupStates())),
+	}
+
+	if task.GetRetryAfter() != nil {
+		retryAfter := task.GetRetryAfter().AsTime()
+		result.RetryAfter = &retryAfter
+	}
+
+	if info, ok := task.(cms.MaintenanceTaskInfo); ok {
+		result.Description = info.GetTaskOptions().GetDescription()
+		if info.GetCreateTime() != nil {
+			createTime := info.GetCreateTime().AsTime()
+			result.CreateTime = &createTime
+		}
+	}
+
+	for _, gs := range task.GetActionGroupStates() {
+		group := ActionGroup{
+			Actions: make([]Action, 0, len(gs.GetActionStates())),
+		}
+		for _, as := range gs.GetActionStates() {
+			group.Actions = append(group.Actions, actionToStructured(as))
+		}
+		result.ActionGroups = append(result.ActionGroups, group)
+	}
+
+	return result
+}
+
+func TasksToStructured(tasks []cms.MaintenanceTask) []Task {
+	result := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, TaskToStructured(task))
+	}
+	return result
+}
+
+func ResultToStructured(result *Ydb_Maintenance.ManageActionResult) ActionResult {
+	structured := ActionResult{
+		ActionStatuses: make([]ActionStatus, 0, len(result.GetActionStatuses())),
+	}
+
+	for _, status := range result.GetActionStatuses() {
+		structured.ActionStatuses = append(structured.ActionStatuses, ActionStatus{
+			ActionUID: actionUIDToStructured(status.GetActionUid()),
+			Status:    enumName(status.GetStatus().String(), "STATUS_CODE_"),
+		})
+	}
+
+	return structured
+}
+
+// Marshal serializes a structured value in one of the non-text output formats.
+func Marshal(format string, v any) (string, error) {
+	switch format {
+	case options.OutputJSON:
+		content, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to serialize output to json: %w", err)
+		}
+		return string(content) + "\n", nil
+	case options.OutputYAML:
+		content, err := yaml.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to serialize output to yaml: %w", err)
+		}
+		return string(content), nil
+	default:
+		return "", fmt.Errorf("output format %s can not be used for structured output", format)
+	}
+}
+
+func actionToStructured(as *Ydb_Maintenance.ActionState) Action {
+	action := Action{
+		ActionUID: actionUIDToStructured(as.GetActionUid()),
+		Status:    enumName(as.GetStatus().String(), "ACTION_STATUS_"),
+		Details:   as.GetDetails(),
+	}
+
+	if as.GetReason() != Ydb_Maintenance.ActionState_ACTION_REASON_UNSPECIFIED {
+		action.Reason = enumName(as.GetReason().String(), "ACTION_REASON_")
+	}
+
+	if as.GetDeadline() != nil {
+		deadline := as.GetDeadline().AsTime()
+		action.Deadline = &deadline
+	}
+
+	if lock := as.GetAction().GetLockAction(); lock != nil {
+		action.NodeID, action.Host = cms.ScopeNodeAndHost(lock.GetScope())
+		if pdisk, ok := cms.PDiskFromScope(lock.GetScope()); ok {
+			action.PDisk = pdisk.String()
+		}
+	}
+
+	return action
+}
+
+func actionUIDToStructured(uid *Ydb_Maintenance.ActionUid) ActionUID {
+	return ActionUID{
+		TaskUID:  uid.GetTaskUid(),
+		GroupID:  uid.GetGroupId(),
+		ActionID: uid.GetActionId(),
+	}
+}
+
+// enumName strips the type prefix from the name of an enum value. Status
+// codes only have it on STATUS_CODE_UNSPECIFIED, e.g. SUCCESS has none.
+func enumName(name, prefix string) string {
+	return strings.TrimPrefix(name, prefix)
+}
@@ -0,0 +1,267 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	maskedValue = "********"
+)
+
+// Options that point to credentials. Their values are hidden by `ydbops profile show`
+// unless the user explicitly asks to reveal them.
+var secretOptionNames = map[string]bool{
+	"password-file": true,
+	"token-file":    true,
+	"sa-key-file":   true,
+}
+
+type Profile map[string]string
+
+// ErrProfileNotFound is wrapped by the errors about a profile missing in the profile file.
+var ErrProfileNotFound = errors.New("not found in your profile file")
+
+// Config is the in-memory representation of the profile file:
+//
+//	current-profile: my-profile
+//	profiles:
+//	  my-profile:
+//	    endpoint: grpcs://localhost:2135
+type Config struct {
+	CurrentProfile string             `yaml:"current-profile,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+func DefaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "ydb", "ydbops", "config", "config.yaml")
+}
+
+// LoadConfig reads the profile file. A missing file is not an error, an empty
+// config is returned instead, so that the first profile can be created.
+func LoadConfig(configFile string) (*Config, error) {
+	config := &Config{
+		Profiles: make(map[string]Profile),
+	}
+
+	fileContent, err := os.ReadFile(configFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config file on path %s: %w", configFile, err)
+	}
+
+	if err = yaml.Unmarshal(fileContent, config); err != nil {
+		return nil, fmt.Errorf("failed to parse the config file on path %s: %w", configFile, err)
+	}
+
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]Profile)
+	}
+
+	return config, nil
+}
+
+// SaveConfig writes the config through a temporary file and a rename, so that
+// an interrupted write never leaves a truncated profile file behind. The file is
+// rewritten from the config as a whole: comments, key order and keys unknown to
+// Config are not preserved.
+func SaveConfig(configFile string, config *Config) error {
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to serialize the config: %w", err)
+	}
+
+	dir := filepath.Dir(configFile)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(configFile)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary config file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the config: %w", err)
+	}
+	if err = tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set permissions on the config: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the config: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), configFile); err != nil {
+		return fmt.Errorf("failed to replace the config file on path %s: %w", configFile, err)
+	}
+
+	return nil
+}
+
+// SupportedOptions returns the names of all flags that can be specified in a profile.
+func SupportedOptions() []string {
+	names := make([]string, 0, len(pointersToProgramOptions))
+	for name := range pointersToProgramOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func IsSupportedOption(name string) bool {
+	_, ok := pointersToProgramOptions[name]
+	return ok
+}
+
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveName picks the profile to operate on: the explicitly requested one,
+// otherwise the one marked as `current-profile`.
+func (c *Config) ResolveName(profileName string) (string, error) {
+	if profileName != "" {
+		return profileName, nil
+	}
+
+	if c.CurrentProfile == "" {
+		return "", fmt.Errorf(
+			"failed to get current profile: field `%s` absent in config, and profile name unspecified",
+			activeProfileKeyName,
+		)
+	}
+
+	return c.CurrentProfile, nil
+}
+
+func (c *Config) Get(profileName string) (Profile, error) {
+	p, ok := c.Profiles[profileName]
+	if !ok {
+		return nil, fmt.Errorf("profile `%s` %w", profileName, ErrProfileNotFound)
+	}
+	return p, nil
+}
+
+func (c *Config) Delete(profileName string) error {
+	if _, ok := c.Profiles[profileName]; !ok {
+		return fmt.Errorf("profile `%s` %w", profileName, ErrProfileNotFound)
+	}
+
+	delete(c.Profiles, profileName)
+	if c.CurrentProfile == profileName {
+		c.CurrentProfile = ""
+	}
+
+	return nil
+}
+
+func (c *Config) Activate(profileName string) error {
+	if _, ok := c.Profiles[profileName]; !ok {
+		return fmt.Errorf("profile `%s` %w", profileName, ErrProfileNotFound)
+	}
+
+	c.CurrentProfile = profileName
+	return nil
+}
+
+// Validate checks that `current-profile` points to an existing profile and
+// that every profile only contains options supported by ydbops.
+func (c *Config) Validate() error {
+	problems := []string{}
+
+	if c.CurrentProfile != "" {
+		if _, ok := c.Profiles[c.CurrentProfile]; !ok {
+			problems = append(problems, fmt.Sprintf(
+				"`%s` points to profile `%s`, which does not exist",
+				activeProfileKeyName, c.CurrentProfile,
+			))
+		}
+	}
+
+	for _, name := range c.ProfileNames() {
+		if err := c.Profiles[name].Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("profile `%s`: %s", name, err))
+		}
+		if _, err := c.Resolve(name); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("config file is invalid:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+func (p Profile) Validate() error {
+	unsupported := []string{}
+	for _, key := range p.Keys() {
+		if key != extendsKeyName && !IsSupportedOption(key) {
+			unsupported = append(unsupported, key)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf(
+			"unsupported fields: %s. Supported fields: %s",
+			strings.Join(unsupported, ", "),
+			strings.Join(SupportedOptions(), ", "),
+		)
+	}
+
+	return nil
+}
+
+func (p Profile) Keys() []string {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Masked returns a copy of the profile with values of credential options hidden.
+func (p Profile) Masked() Profile {
+	masked := make(Profile, len(p))
+	for key, value := range p {
+		if secretOptionNames[key] && value != "" {
+			masked[key] = maskedValue
+		} else {
+			masked[key] = value
+		}
+	}
+	return masked
+}
+
+// ResolveConfigPath returns the profile file to operate on: the one passed with
+// --profile-file, or the default location if it was unspecified.
+func ResolveConfigPath(profileFile string) string {
+	if profileFile != "" {
+		return profileFile
+	}
+	return DefaultConfigPath()
+}
@@ -0,0 +1,194 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+)
+
+var _ = Describe("Test profile config", func() {
+	var (
+		endpoint     string
+		passwordFile string
+		configPath   string
+	)
+
+	BeforeEach(func() {
+		pointersToProgramOptions = make(map[string][]option)
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		PopulateFromProfileLater(fs.StringVar, &endpoint, "endpoint", "", "")
+		PopulateFromProfileLater(fs.StringVar, &passwordFile, "password-file", "", "")
+
+		configPath = filepath.Join(GinkgoT().TempDir(), "config", "config.yaml")
+	})
+
+	It("missing file is loaded as an empty config", func() {
+		config, err := LoadConfig(configPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.CurrentProfile).To(BeEmpty())
+		Expect(config.Profiles).To(BeEmpty())
+	})
+
+	It("saved config can be loaded back and filled into options", func() {
+		config := &Config{
+			CurrentProfile: "prod",
+			Profiles: map[string]Profile{
+				"prod": {"endpoint": "grpcs://prod:2135"},
+			},
+		}
+		Expect(SaveConfig(configPath, config)).To(Succeed())
+
+		info, err := os.Stat(configPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+
+		loaded, err := LoadConfig(configPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(Equal(config))
+
+		Expect(FillDefaultsFromActiveProfile(configPath, "")).To(Succeed())
+		Expect(endpoint).To(Equal("grpcs://prod:2135"))
+	})
+
+	It("deleting the current profile leaves no current profile", func() {
+		config := &Config{
+			CurrentProfile: "prod",
+			Profiles: map[string]Profile{
+				"prod":    {},
+				"testing": {},
+			},
+		}
+		Expect(config.Delete("prod")).To(Succeed())
+		Expect(config.CurrentProfile).To(BeEmpty())
+		Expect(config.ProfileNames()).To(Equal([]string{"testing"}))
+		Expect(config.Activate("prod")).To(MatchError(ErrProfileNotFound))
+
+		_, err := config.Get("prod")
+		Expect(err).To(MatchError(ErrProfileNotFound))
+	})
+
+	It("validation reports dangling current profile and unsupported fields", func() {
+		config := &Config{
+			CurrentProfile: "absent",
+			Profiles: map[string]Profile{
+				"prod": {"endpoint": "grpcs://prod:2135", "unknown-option": "value"},
+			},
+		}
+		err := config.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("`current-profile` points to profile `absent`"))
+		Expect(err.Error()).To(ContainSubstring("unsupported fields: unknown-option"))
+	})
+
+	It("credential options are masked", func() {
+		masked := Profile{
+			"endpoint":      "grpcs://prod:2135",
+			"password-file": "/secret/password",
+		}.Masked()
+		Expect(masked["endpoint"]).To(Equal("grpcs://prod:2135"))
+		Expect(masked["password-file"]).To(Equal(maskedValue))
+	})
+})
+
+var _ = Describe("Test profile inheritance and interpolation", func() {
+	BeforeEach(func() {
+		pointersToProgramOptions = make(map[string][]option)
+		fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		for _, name := range []string{"endpoint", "kubeconfig", "user", "ca-file"} {
+			var value string
+			PopulateFromProfileLater(fs.StringVar, &value, name, "", "")
+		}
+	})
+
+	It("child options override inherited ones", func() {
+		config := &Config{
+			Profiles: map[string]Profile{
+				"base":   {"user": "admin", "ca-file": "/etc/ca.crt", "endpoint": "grpcs://base:2135"},
+				"prod":   {"extends": "base", "kubeconfig": "/kube/prod"},
+				"prod-a": {"extends": "prod", "endpoint": "grpcs://prod-a:2135"},
+			},
+		}
+
+		resolved, err := config.Resolve("prod-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved).To(Equal(Profile{
+			"user":       "admin",
+			"ca-file":    "/etc/ca.crt",
+			"kubeconfig": "/kube/prod",
+			"endpoint":   "grpcs://prod-a:2135",
+		}))
+		Expect(config.Validate()).To(Succeed())
+	})
+
+	It("inheritance cycles are detected", func() {
+		config := &Config{
+			Profiles: map[string]Profile{
+				"a": {"extends": "b"},
+				"b": {"extends": "c"},
+				"c": {"extends": "a"},
+			},
+		}
+
+		_, err := config.Resolve("a")
+		Expect(err).To(MatchError(ContainSubstring("cycle detected: a -> b -> c -> a")))
+	})
+
+	It("missing parent is reported", func() {
+		config := &Config{
+			Profiles: map[string]Profile{
+				"a": {"extends": "absent"},
+			},
+		}
+
+		_, err := config.Resolve("a")
+		Expect(err).To(MatchError(ContainSubstring("profile `a` extends profile `absent`")))
+	})
+
+	It("inheritance is checked without interpolating references", func() {
+		config := &Config{
+			Profiles: map[string]Profile{
+				"a":    {"extends": "b", "user": "${YDBOPS_TEST_UNSET}"},
+				"b":    {"extends": "b"},
+				"base": {"user": "${YDBOPS_TEST_UNSET}"},
+				"c":    {"extends": "base"},
+			},
+		}
+
+		Expect(config.CheckInheritance("a")).To(MatchError(ContainSubstring("cycle detected: a -> b -> b")))
+		Expect(config.CheckInheritance("c")).To(Succeed())
+	})
+
+	It("environment variables and files are interpolated", func() {
+		GinkgoT().Setenv("YDBOPS_TEST_CLUSTER", "prod-b")
+		userFile := filepath.Join(GinkgoT().TempDir(), "user")
+		Expect(os.WriteFile(userFile, []byte("robot\n"), 0o600)).To(Succeed())
+
+		config := &Config{
+			Profiles: map[string]Profile{
+				"base": {"endpoint": "grpcs://${YDBOPS_TEST_CLUSTER}.ydb.tech:2135"},
+				"b":    {"extends": "base", "user": "${file:" + userFile + "}"},
+			},
+		}
+
+		resolved, err := config.Resolve("b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolved["endpoint"]).To(Equal("grpcs://prod-b.ydb.tech:2135"))
+		Expect(resolved["user"]).To(Equal("robot"))
+	})
+
+	It("unset environment variable is an error", func() {
+		config := &Config{
+			Profiles: map[string]Profile{
+				"a": {"endpoint": "grpcs://${YDBOPS_TEST_SURELY_UNSET}:2135"},
+			},
+		}
+
+		_, err := config.Resolve("a")
+		Expect(err).To(MatchError(ContainSubstring(
+			"profile `a`, field `endpoint`: environment variable YDBOPS_TEST_SURELY_UNSET",
+		)))
+	})
+})
@@ -3,8 +3,6 @@ package profile
 import (
 	"fmt"
 	"os"
-
-	"gopkg.in/yaml.v2"
 )
 
 type option struct {
@@ -23,7 +21,6 @@ var pointersToProgramOptions = make(map[string][]option)
 
 const (
 	activeProfileKeyName = "current-profile"
-	profileArrayKeyName  = "profiles"
 )
 
 func FillDefaultsFromActiveProfile(configFile, profileName string) error {
@@ -35,45 +32,39 @@ func FillDefaultsFromActiveProfile(configFile, profileName string) error {
 		return fmt.Errorf("specified --profile, but unspecified --config-path")
 	}
 
-	data := make(map[string]any)
-	fileContent, err := os.ReadFile(configFile)
-	if err != nil {
+	if _, err := os.Stat(configFile); err != nil {
 		return fmt.Errorf("failed to read the config file on path %s: %w", configFile, err)
 	}
 
-	err = yaml.Unmarshal(fileContent, &data)
+	config, err := LoadConfig(configFile)
 	if err != nil {
 		return err
 	}
 
-	if currentProfile, present := data[activeProfileKeyName]; profileName == "" && present {
-		profileName = currentProfile.(string)
-		delete(data, activeProfileKeyName)
-	} else if profileName == "" {
+	if profileName == "" && config.CurrentProfile == "" {
 		return fmt.Errorf(
 			"failed to get current profile: field `%s` absent in config, and --profile flag unspecified",
 			activeProfileKeyName,
 		)
 	}
 
-	profilesArray, ok := data[profileArrayKeyName]
-	if !ok {
-		return fmt.Errorf("config file is malformed, `%s` field not found", profileArrayKeyName)
-	}
+	profileName, _ = config.ResolveName(profileName)
 
-	profile, ok := profilesArray.(map[any]any)[profileName]
-	if !ok {
-		return fmt.Errorf("profile `%s` not found in your profile file", profileName)
+	// `extends` and ${...} references are resolved before anything is
+	// applied, so that a broken profile does not fill options partially.
+	profile, err := config.Resolve(profileName)
+	if err != nil {
+		return err
 	}
 
-	for optionName, valueFromFile := range profile.(map[any]any) {
-		options, ok := pointersToProgramOptions[optionName.(string)]
+	for optionName, valueFromFile := range profile {
+		options, ok := pointersToProgramOptions[optionName]
 		if !ok {
 			return fmt.Errorf("profile `%s` contains unsupported field `%s`", profileName, optionName)
 		}
 		for _, option := range options {
 			if *option.ptr == option.defaultValue {
-				*option.ptr = valueFromFile.(string)
+				*option.ptr = valueFromFile
 			}
 		}
 	}
@@ -0,0 +1,133 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// A profile with this key inherits all options of the named profile,
+	// its own options take precedence over the inherited ones.
+	extendsKeyName = "extends"
+
+	fileInterpolationPrefix = "file:"
+)
+
+// Matches ${ENV_VAR} and ${file:/path/to/file}
+var interpolationRegexp = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Resolve returns the options of the profile with all `extends` ancestors merged
+// in and all ${...} references interpolated.
+func (c *Config) Resolve(profileName string) (Profile, error) {
+	merged, err := c.mergeWithAncestors(profileName, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(Profile, len(merged))
+	for key, value := range merged {
+		interpolated, err := interpolate(value)
+		if err != nil {
+			return nil, fmt.Errorf("profile `%s`, field `%s`: %w", profileName, key, err)
+		}
+		resolved[key] = interpolated
+	}
+
+	return resolved, nil
+}
+
+// CheckInheritance returns an error if the `extends` chain of the profile has a cycle
+// or names a profile that does not exist. Unlike Resolve, ${...} references are not
+// interpolated: they may only be resolvable where the profile is used.
+func (c *Config) CheckInheritance(profileName string) error {
+	_, err := c.mergeWithAncestors(profileName, []string{})
+	return err
+}
+
+func (c *Config) mergeWithAncestors(profileName string, chain []string) (Profile, error) {
+	chain = append(chain, profileName)
+	for _, seen := range chain[:len(chain)-1] {
+		if seen == profileName {
+			return nil, fmt.Errorf("profile inheritance cycle detected: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	p, ok := c.Profiles[profileName]
+	if !ok {
+		if len(chain) > 1 {
+			return nil, fmt.Errorf(
+				"profile `%s` extends profile `%s`, which is not found in your profile file",
+				chain[len(chain)-2], profileName,
+			)
+		}
+		return nil, fmt.Errorf("profile `%s` not found in your profile file", profileName)
+	}
+
+	merged := Profile{}
+	if parent, present := p[extendsKeyName]; present {
+		if parent == "" {
+			return nil, fmt.Errorf("profile `%s` has an empty `%s` field", profileName, extendsKeyName)
+		}
+
+		inherited, err := c.mergeWithAncestors(parent, chain)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range inherited {
+			merged[key] = value
+		}
+	}
+
+	for key, value := range p {
+		if key != extendsKeyName {
+			merged[key] = value
+		}
+	}
+
+	return merged, nil
+}
+
+func interpolate(value string) (string, error) {
+	var firstErr error
+
+	result := interpolationRegexp.ReplaceAllStringFunc(value, func(match string) string {
+		reference := interpolationRegexp.FindStringSubmatch(match)[1]
+
+		substitution, err := resolveReference(reference)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return substitution
+	})
+
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return result, nil
+}
+
+func resolveReference(reference string) (string, error) {
+	if path, isFile := strings.CutPrefix(reference, fileInterpolationPrefix); isFile {
+		if path == "" {
+			return "", fmt.Errorf("empty file path in ${%s}", reference)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file referenced in ${%s}: %w", reference, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+
+	if reference == "" {
+		return "", fmt.Errorf("empty reference ${}")
+	}
+
+	value, present := os.LookupEnv(reference)
+	if !present {
+		return "", fmt.Errorf("environment variable %s referenced in ${%s} is not set", reference, reference)
+	}
+	return value, nil
+}
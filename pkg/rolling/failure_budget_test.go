@@ -0,0 +1,101 @@
+package rolling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/pkg/notify"
+)
+
+var _ = Describe("Test failure budget", func() {
+	var (
+		server  *httptest.Server
+		mu      sync.Mutex
+		events  []notify.Event
+		rolling *Rolling
+	)
+
+	failedStatus := func(nodeID uint32) restartStatus {
+		return restartStatus{
+			as: &Ydb_Maintenance.ActionState{
+				ActionUid: &Ydb_Maintenance.ActionUid{TaskUid: "rolling-restart-1"},
+				Action: &Ydb_Maintenance.Action{
+					Action: &Ydb_Maintenance.Action_LockAction{
+						LockAction: &Ydb_Maintenance.LockAction{
+							Scope: &Ydb_Maintenance.ActionScope{
+								Scope: &Ydb_Maintenance.ActionScope_NodeId{NodeId: nodeID},
+							},
+						},
+					},
+				},
+			},
+			err: errors.New("disk busy"),
+		}
+	}
+
+	BeforeEach(func() {
+		events = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			event := notify.Event{}
+			Expect(json.Unmarshal(body, &event)).To(Succeed())
+
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}))
+
+		opts := &RestartOptions{RestartRetryNumber: 1, MaxFailedNodes: 2}
+		opts.Notify.WebhookURL = server.URL
+		Expect(opts.Notify.Validate()).To(Succeed())
+
+		rolling = &Rolling{
+			logger: zap.S(),
+			opts:   opts,
+			state: &state{
+				nodes: map[uint32]*Ydb_Maintenance.Node{
+					1: {NodeId: 1},
+					2: {NodeId: 2},
+				},
+				retriesMadeForScope: map[string]int{},
+				finishedScopes:      map[string]bool{},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("stops the restart and reports it once --max-failed-nodes nodes have failed", func() {
+		statuses := make(chan restartStatus, 1)
+		statuses <- failedStatus(1)
+		rolling.handleRestartStatus(context.Background(), statuses, 1)
+		Expect(rolling.checkFailureBudget("rolling-restart-1")).To(Succeed())
+
+		statuses <- failedStatus(2)
+		rolling.handleRestartStatus(context.Background(), statuses, 1)
+		err := rolling.checkFailureBudget("rolling-restart-1")
+		Expect(err).To(MatchError(
+			"2 nodes have failed to restart, --max-failed-nodes is 2, stopping the restart",
+		))
+		Expect(err).To(MatchError(errFailureBudgetExceeded))
+
+		rolling.opts.Notify.Notifier().Close()
+		Expect(events).To(HaveLen(3))
+		Expect(events[2].Type).To(Equal(notify.EventBudgetExceeded))
+		Expect(events[2].TaskUID).To(Equal("rolling-restart-1"))
+		Expect(events[2].FailedNodes).To(Equal(2))
+		Expect(events[2].MaxFailedNodes).To(Equal(2))
+	})
+})
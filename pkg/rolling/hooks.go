@@ -0,0 +1,167 @@
+package rolling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/pkg/notify"
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+)
+
+const (
+	HookFailureFail = "fail"
+	HookFailureSkip = "skip"
+
+	RestartResultEnvVar = "YDB_RESTART_RESULT"
+	RestartErrorEnvVar  = "YDB_RESTART_ERROR"
+	RunResultEnvVar     = "YDB_RUN_RESULT"
+	RunErrorEnvVar      = "YDB_RUN_ERROR"
+
+	resultOK     = "ok"
+	resultFailed = "failed"
+)
+
+var HookFailureChoices = []string{HookFailureFail, HookFailureSkip}
+
+// errNodeSkipped is returned by nodeHooks.before when the node must not be
+// restarted, but its action must be completed.
+var errNodeSkipped = errors.New("node skipped")
+
+// nodeHooks runs --pre-node-hook and --post-node-hook around every restart.
+type nodeHooks struct {
+	logger *zap.SugaredLogger
+	opts   *RestartOptions
+}
+
+func (h *nodeHooks) before(ctx context.Context, node *Ydb_Maintenance.Node, attempt restarters.RestartAttempt) error {
+	if h.opts.PreNodeHook == "" {
+		return nil
+	}
+
+	err := runHook(ctx, h.logger, h.opts.PreNodeHook, restarters.NodeEnv(node, attempt))
+	if err == nil {
+		return nil
+	}
+
+	if h.opts.PreNodeHookFailure == HookFailureSkip && ctx.Err() == nil {
+		h.logger.Warnf("Pre-node hook failed for node %d, skipping the node: %v", node.GetNodeId(), err)
+		return errNodeSkipped
+	}
+	return fmt.Errorf("pre-node hook failed for node %d: %w", node.GetNodeId(), err)
+}
+
+// after runs even if the restart has failed, the hook gets the outcome
+// in its environment.
+func (h *nodeHooks) after(
+	ctx context.Context,
+	node *Ydb_Maintenance.Node,
+	attempt restarters.RestartAttempt,
+	restartErr error,
+) error {
+	if h.opts.PostNodeHook == "" {
+		return nil
+	}
+
+	env := append(restarters.NodeEnv(node, attempt), resultEnv(RestartResultEnvVar, RestartErrorEnvVar, restartErr)...)
+	if err := runHook(ctx, h.logger, h.opts.PostNodeHook, env); err != nil {
+		return fmt.Errorf("post-node hook failed for node %d: %w", node.GetNodeId(), err)
+	}
+	return nil
+}
+
+// RunWithHooks runs --pre-run-hook, the whole rolling restart and --post-run-hook,
+// and notifies about the start and the end of the restart. The post-run hook
+// runs even if the restart has failed. A failing pre-run hook cancels the restart.
+func RunWithHooks(logger *zap.SugaredLogger, opts *RestartOptions, run func() error) error {
+	if opts.PlanOut != "" {
+		// nothing is restarted, only planned
+		return run()
+	}
+
+	notifier := opts.Notify.Notifier()
+	defer notifier.Close()
+
+	if opts.PreRunHook != "" {
+		if err := runHook(context.Background(), logger, opts.PreRunHook, nil); err != nil {
+			return fmt.Errorf("pre-run hook failed: %w", err)
+		}
+	}
+
+	notifier.Notify(notify.NewEvent(notify.EventStarted, "Rolling restart started"))
+
+	err := run()
+
+	finished := notify.NewEvent(notify.EventFinished, "Rolling restart completed successfully")
+	if err != nil {
+		finished.Message = "Rolling restart failed"
+		finished.Error = err.Error()
+	}
+	notifier.Notify(finished)
+
+	if opts.PostRunHook != "" {
+		postRunEnv := resultEnv(RunResultEnvVar, RunErrorEnvVar, err)
+		if hookErr := runHook(context.Background(), logger, opts.PostRunHook, postRunEnv); hookErr != nil {
+			return errors.Join(err, fmt.Errorf("post-run hook failed: %w", hookErr))
+		}
+	}
+	return err
+}
+
+// runHook runs the hook until it exits or ctx is done. Then the hook and
+// everything it has started are killed.
+func runHook(ctx context.Context, logger *zap.SugaredLogger, path string, env []string) error {
+	logger.Infof("Running hook %s", path)
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	stdout, _ := cmd.StdoutPipe()
+	stderr, _ := cmd.StderrPipe()
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error running hook: %w", err)
+	}
+
+	// the output must be read before Wait closes the pipes
+	var wg sync.WaitGroup
+	for _, pipe := range []io.ReadCloser{stdout, stderr} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			restarters.StreamPipeIntoLogger(pipe, logger)
+		}()
+	}
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("hook was killed: %w", context.Cause(ctx))
+		}
+		return err
+	}
+	return nil
+}
+
+func resultEnv(resultVar, errorVar string, err error) []string {
+	if err == nil {
+		return []string{resultVar + "=" + resultOK}
+	}
+	return []string{
+		resultVar + "=" + resultFailed,
+		errorVar + "=" + err.Error(),
+	}
+}
@@ -0,0 +1,171 @@
+package rolling
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
+)
+
+// The duration of a maintenance task is estimated upfront, see GetRestartDuration.
+// When CMS grants locks slowly, or grants many at once, nodes may wait for their
+// restart longer than their locks last. CMS can not prolong a lock and never grants
+// a lock held by another task, so with --extend-locks the nodes whose locks expire
+// before their restart are requested again: a follow-up task is queued for them and
+// for the nodes still waiting, and the current task is dropped once the nodes
+// restarted now are completed. The wave goes on with the follow-up task.
+
+func (r *Rolling) startWave(budget time.Duration) {
+	r.state.waveStarted = time.Now()
+	r.state.waveBudget = budget
+	r.state.budgetWarned = false
+	r.state.followUpTask = nil
+}
+
+// requestExpiringLocksAgain returns the groups of the queue to restart now. The
+// other groups of the task are requested again in a follow-up task, unless it
+// can not be created.
+func (r *Rolling) requestExpiringLocksAgain(
+	actions, queue []*Ydb_Maintenance.ActionGroupStates,
+) []*Ydb_Maintenance.ActionGroupStates {
+	restart, expiring := r.splitByLockDeadline(queue, time.Now())
+	if len(expiring) == 0 {
+		return queue
+	}
+
+	nodes := []*Ydb_Maintenance.Node{}
+	for _, gs := range append(expiring, r.waitingGroups(actions)...) {
+		for _, as := range gs.GetActionStates() {
+			nodes = append(nodes, r.nodesOf(as.GetAction().GetLockAction().GetScope())...)
+		}
+	}
+
+	taskParams := r.taskParams(RestartTaskPrefix+uuid.New().String(), nodes)
+	// remembered before the request, like the task of the wave
+	r.state.createdTaskUIDs = append(r.state.createdTaskUIDs, taskParams.TaskUID)
+
+	task, err := r.cms.CreateMaintenanceTask(taskParams)
+	if err != nil {
+		r.logger.Warnf("Failed to request the expiring locks again, restarting the nodes with the locks they have: %v", err)
+		return queue
+	}
+
+	r.logger.Infof(
+		"Requested the locks of %d groups again in maintenance task %s, the current task is dropped once %d groups are restarted",
+		len(expiring), task.GetTaskUid(), len(restart),
+	)
+	r.state.followUpTask = task
+	return restart
+}
+
+// splitByLockDeadline splits the queue into the groups to restart now and the
+// groups whose locks expire before they are expected to be restarted: after the
+// groups ahead of them, --nodes-inflight at a time. The first --nodes-inflight
+// groups are always restarted, they can not be restarted any sooner.
+func (r *Rolling) splitByLockDeadline(
+	queue []*Ydb_Maintenance.ActionGroupStates,
+	now time.Time,
+) (restart, expiring []*Ydb_Maintenance.ActionGroupStates) {
+	inflight := max(r.opts.NodesInflight, 1)
+	for _, gs := range queue {
+		if len(restart) < inflight {
+			restart = append(restart, gs)
+			continue
+		}
+
+		expected := now.Add(r.expectedRestartTime(append(restart, gs)))
+		expires := false
+		for _, as := range gs.GetActionStates() {
+			if as.GetDeadline() != nil && as.GetDeadline().AsTime().Before(expected) {
+				expires = true
+				r.logger.Infof(
+					"Lock of %s expires at %s, before its restart is expected to finish at %s",
+					cms.ScopeToString(as.GetAction().GetLockAction().GetScope()),
+					as.GetDeadline().AsTime().Format(time.DateTime), expected.Format(time.DateTime),
+				)
+			}
+		}
+
+		if expires {
+			expiring = append(expiring, gs)
+		} else {
+			restart = append(restart, gs)
+		}
+	}
+	return restart, expiring
+}
+
+// waitingGroups are the groups of the task CMS has not granted yet.
+func (r *Rolling) waitingGroups(actions []*Ydb_Maintenance.ActionGroupStates) []*Ydb_Maintenance.ActionGroupStates {
+	waiting := []*Ydb_Maintenance.ActionGroupStates{}
+	for _, gs := range actions {
+		for _, as := range gs.GetActionStates() {
+			if as.GetStatus() != Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
+				waiting = append(waiting, gs)
+				break
+			}
+		}
+	}
+	return waiting
+}
+
+// switchToFollowUp drops the task whose expiring locks were requested again
+// and returns the follow-up task, which holds them from now on.
+func (r *Rolling) switchToFollowUp(taskUID string) cms.MaintenanceTask {
+	task := r.state.followUpTask
+	r.state.followUpTask = nil
+
+	if err := r.cms.DropTask(taskUID); err != nil {
+		r.logger.Warnf(
+			"Failed to drop maintenance task %s, CMS grants its locks to task %s once they expire: %v",
+			taskUID, task.GetTaskUid(), err,
+		)
+	}
+	r.logger.Infof("Maintenance task %s continues the restart of task %s", task.GetTaskUid(), taskUID)
+	return task
+}
+
+// warnAboutLockBudget warns once per wave when the groups left in the task
+// are expected to be restarted after the duration the task was created for.
+func (r *Rolling) warnAboutLockBudget(remaining []*Ydb_Maintenance.ActionGroupStates) {
+	if r.state.budgetWarned {
+		return
+	}
+
+	elapsed := time.Since(r.state.waveStarted)
+	left := r.expectedRestartTime(remaining)
+	if elapsed+left <= r.state.waveBudget {
+		return
+	}
+
+	r.state.budgetWarned = true
+	hint := "locks of the waiting nodes that expire before their restart will be requested again"
+	if !r.opts.ExtendLocks {
+		hint = "locks of the waiting nodes may expire, CMS treats any further maintenance as a regular failure"
+	}
+	r.logger.Warnf(
+		"The restart is taking longer than the %s its locks were requested for: %s elapsed, about %s left, %s",
+		r.state.waveBudget, elapsed.Round(time.Second), left, hint,
+	)
+}
+
+// expectedRestartTime is how long the groups take to restart, --nodes-inflight
+// at a time. Unlike the duration of the task, it expects every restart to succeed
+// at once: failed attempts make the restart take longer and are noticed later.
+func (r *Rolling) expectedRestartTime(groups []*Ydb_Maintenance.ActionGroupStates) time.Duration {
+	// nodes locked by one action are restarted one after another
+	maxNodesPerAction := 1
+	for _, gs := range groups {
+		for _, as := range gs.GetActionStates() {
+			if lock := as.GetAction().GetLockAction(); lock != nil {
+				maxNodesPerAction = max(maxNodesPerAction, len(r.nodesOf(lock.GetScope())))
+			}
+		}
+	}
+	inflight := max(r.opts.NodesInflight, 1)
+	batches := (len(groups) + inflight - 1) / inflight
+	batch := time.Duration(r.opts.RestartDuration)*time.Second + r.opts.DelayBetweenRestarts
+	return time.Duration(batches*maxNodesPerAction) * batch
+}
@@ -0,0 +1,70 @@
+package rolling
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("Test lock deadlines", func() {
+	var (
+		now     = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		rolling *Rolling
+	)
+
+	group := func(nodeID uint32, deadline time.Time) *Ydb_Maintenance.ActionGroupStates {
+		return &Ydb_Maintenance.ActionGroupStates{
+			ActionStates: []*Ydb_Maintenance.ActionState{{
+				Status:   Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED,
+				Deadline: timestamppb.New(deadline),
+				Action: &Ydb_Maintenance.Action{
+					Action: &Ydb_Maintenance.Action_LockAction{
+						LockAction: &Ydb_Maintenance.LockAction{
+							Scope: &Ydb_Maintenance.ActionScope{
+								Scope: &Ydb_Maintenance.ActionScope_NodeId{NodeId: nodeID},
+							},
+						},
+					},
+				},
+			}},
+		}
+	}
+
+	BeforeEach(func() {
+		rolling = &Rolling{
+			logger: zap.S(),
+			opts:   &RestartOptions{NodesInflight: 1, RestartDuration: 60},
+			state: &state{
+				nodes: map[uint32]*Ydb_Maintenance.Node{
+					1: {NodeId: 1},
+					2: {NodeId: 2},
+					3: {NodeId: 3},
+				},
+			},
+		}
+	})
+
+	It("requests again the groups whose locks expire before their turn", func() {
+		first := group(1, now.Add(time.Minute))
+		expiring := group(2, now.Add(90*time.Second))
+		lasting := group(3, now.Add(3*time.Minute))
+
+		restart, again := rolling.splitByLockDeadline(
+			[]*Ydb_Maintenance.ActionGroupStates{first, expiring, lasting}, now,
+		)
+		Expect(restart).To(Equal([]*Ydb_Maintenance.ActionGroupStates{first, lasting}))
+		Expect(again).To(Equal([]*Ydb_Maintenance.ActionGroupStates{expiring}))
+	})
+
+	It("always restarts the first --nodes-inflight groups", func() {
+		expired := group(1, now.Add(-time.Minute))
+
+		restart, again := rolling.splitByLockDeadline([]*Ydb_Maintenance.ActionGroupStates{expired}, now)
+		Expect(restart).To(Equal([]*Ydb_Maintenance.ActionGroupStates{expired}))
+		Expect(again).To(BeEmpty())
+	})
+})
@@ -1,35 +1,50 @@
 package rolling
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 	"google.golang.org/protobuf/types/known/durationpb"
 
+	"github.com/ydb-platform/ydbops/internal/collections"
+	"github.com/ydb-platform/ydbops/pkg/notify"
 	"github.com/ydb-platform/ydbops/pkg/options"
 	"github.com/ydb-platform/ydbops/pkg/utils"
 )
 
 const (
-	DefaultRetryCount              = 3
-	DefaultCMSQueryIntervalSeconds = 10
-	DefaultRestartDurationSeconds  = 60
-	DefaultNodesInflight           = 1
-	DefaultDelayBetweenRestarts    = time.Second
-	DefaultTenantsInflight         = 0
+	DefaultRetryCount                 = 3
+	DefaultCMSQueryIntervalSeconds    = 10
+	DefaultCMSQueryMaxIntervalSeconds = 60
+	DefaultRestartDurationSeconds     = 60
+	DefaultNodesInflight              = 1
+	DefaultDelayBetweenRestarts       = time.Second
+	DefaultTenantsInflight            = 0
 )
 
 type RestartOptions struct {
 	options.TargetingOptions
+	options.GroupingOptions
 
 	RestartRetryNumber         int
+	MaxFailedNodes             int
 	CMSQueryInterval           int
+	CMSQueryMaxInterval        int
 	NodesInflight              int
 	DelayBetweenRestarts       time.Duration
+	NodeRestartTimeout         time.Duration
 	SuppressCompatibilityCheck bool
 	CleanupOnExit              bool
+	DropStaleRollingTasks      bool
+	ExtendLocks                bool
+	Order                      string
+	HostLevel                  bool
 
 	TenantsInflight int
 
@@ -38,42 +53,125 @@ type RestartOptions struct {
 	SSHArgs []string
 
 	CustomSystemdUnitName string
+	TenantSystemdUnitName string
+
+	PreNodeHook        string
+	PostNodeHook       string
+	PreNodeHookFailure string
+	PreRunHook         string
+	PostRunHook        string
+
+	Notify notify.Options
+
+	PlanOut  string
+	PlanFile string
+
+	// plan is loaded from --plan, planOut is being written to --plan-out
+	plan    *Plan
+	planOut *Plan
 }
 
 var rawSSHUnparsedArgs string
 
 func (o *RestartOptions) Validate() error {
+	if o.PlanFile != "" && o.PlanOut != "" {
+		return fmt.Errorf("--plan and --plan-out can not be used together")
+	}
+
+	if o.PlanFile != "" {
+		plan, err := LoadPlan(o.PlanFile)
+		if err != nil {
+			return err
+		}
+		o.plan = plan
+	}
+
 	err := o.TargetingOptions.Validate()
 	if err != nil {
 		return err
 	}
 
+	if err = o.GroupingOptions.Validate(); err != nil {
+		return err
+	}
+
 	if o.CMSQueryInterval < 0 {
 		return fmt.Errorf("specified invalid cms query interval seconds: %d. Must be positive", o.CMSQueryInterval)
 	}
 
+	if o.CMSQueryMaxInterval <= 0 {
+		return fmt.Errorf("specified invalid cms query max interval seconds: %d. Must be positive", o.CMSQueryMaxInterval)
+	}
+
 	if o.RestartRetryNumber < 0 {
 		return fmt.Errorf("specified invalid restart retry number: %d. Must be positive", o.RestartRetryNumber)
 	}
 
+	if o.MaxFailedNodes < 0 {
+		return fmt.Errorf("specified invalid max failed nodes: %d. Must not be negative", o.MaxFailedNodes)
+	}
+
+	if o.NodeRestartTimeout < 0 {
+		return fmt.Errorf("specified invalid node restart timeout: %s. Must not be negative", o.NodeRestartTimeout)
+	}
+
 	if o.RestartDuration < 0 {
 		return fmt.Errorf("specified invalid restart duration: %d. Must be positive", o.RestartDuration)
 	}
 
+	if !collections.Contains(OrderChoices, o.Order) {
+		return fmt.Errorf("specified a non-existing --order: %s", o.Order)
+	}
+
+	if o.HostLevel && o.GroupBy != options.GroupByNone {
+		return fmt.Errorf("--host-level can not be used together with --group-by")
+	}
+
 	if o.TenantsInflight < 0 {
 		return fmt.Errorf("specified invalid inflight tenants: %d. Must be positive", o.TenantsInflight)
 	}
 
+	if !collections.Contains(HookFailureChoices, o.PreNodeHookFailure) {
+		return fmt.Errorf("specified a non-existing --pre-node-hook-failure: %s", o.PreNodeHookFailure)
+	}
+
+	for _, hook := range []string{o.PreNodeHook, o.PostNodeHook, o.PreRunHook, o.PostRunHook} {
+		if hook == "" {
+			continue
+		}
+		if _, err := os.Stat(hook); errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("hook file '%s' does not exist", hook)
+		}
+	}
+
+	if err = o.Notify.Validate(); err != nil {
+		return err
+	}
+
 	o.SSHArgs = utils.ParseSSHArgs(rawSSHUnparsedArgs)
 
 	return nil
 }
 
+// ApplyPlan overrides the options with the settings of --plan, if it is given.
+// Commands call it in Run, before the options are used to build restarters.
+func (o *RestartOptions) ApplyPlan() error {
+	if o.plan == nil {
+		return nil
+	}
+	return o.plan.apply(o)
+}
+
 func (o *RestartOptions) DefineFlags(fs *pflag.FlagSet) {
 	o.TargetingOptions.DefineFlags(fs)
+	o.GroupingOptions.DefineFlags(fs)
 
 	fs.StringVar(&o.CustomSystemdUnitName, "systemd-unit", "", "Specify custom systemd unit name to restart")
 
+	fs.StringVar(&o.TenantSystemdUnitName, "tenant-systemd-unit", "",
+		`Specify custom systemd unit name to restart tenant nodes, overrides --systemd-unit for them.
+'{tenant}' is replaced with the last component of the tenant path, e.g. 'ydbd-{tenant}.service'`)
+
 	fs.StringVar(&rawSSHUnparsedArgs, "ssh-args", "",
 		`This argument will be used when ssh-ing to the nodes. It may be used to override
 the ssh command itself, ssh username or any additional arguments.
@@ -85,8 +183,20 @@ Examples:
 	fs.IntVar(&o.RestartRetryNumber, "restart-retry-number", DefaultRetryCount,
 		fmt.Sprintf("How many times a node should be retried on error, default %v", DefaultRetryCount))
 
+	fs.IntVar(&o.MaxFailedNodes, "max-failed-nodes", 0,
+		`Stop the restart once this many nodes have failed all their attempts, the nodes that are being
+  restarted at that moment are finished first and the maintenance task of the restart is dropped.
+  Zero means the restart goes on no matter how many nodes fail`)
+
 	fs.IntVar(&o.CMSQueryInterval, "cms-query-interval", DefaultCMSQueryIntervalSeconds,
-		fmt.Sprintf("How often to query CMS while waiting for new permissions %v", DefaultCMSQueryIntervalSeconds))
+		fmt.Sprintf(`Minimum time between two CMS queries while waiting for new permissions, in seconds %v.
+  CMS is queried right away after restarted nodes are reported, and less and less often while
+  nothing changes, up to --cms-query-max-interval. A retry-after time reported by CMS is honored`,
+			DefaultCMSQueryIntervalSeconds))
+
+	fs.IntVar(&o.CMSQueryMaxInterval, "cms-query-max-interval", DefaultCMSQueryMaxIntervalSeconds,
+		fmt.Sprintf("Maximum time between two CMS queries while waiting for new permissions, in seconds %v. "+
+			"Never less than --cms-query-interval", DefaultCMSQueryMaxIntervalSeconds))
 
 	fs.IntVar(&o.RestartDuration, "duration", DefaultRestartDurationSeconds,
 		`CMS will release the node for maintenance for duration * restart-retry-number seconds. Any maintenance
@@ -102,19 +212,78 @@ ydbops will try to figure out if you broke this rule by comparing before\after o
 	fs.DurationVar(&o.DelayBetweenRestarts, "delay-between-restarts", DefaultDelayBetweenRestarts,
 		`Delay between two consecutive restarts. E.g. '60s', '2m'. The number of simultaneous restarts is limited by 'nodes-inflight'.`)
 
+	fs.DurationVar(&o.NodeRestartTimeout, "node-restart-timeout", 0,
+		`Give up on a node restart that takes longer than this, e.g. '10m'. The ssh session, the payload
+  and everything the payload has started are killed, and the attempt fails like any other.
+  The pre-node hook counts towards the timeout, the post-node hook gets the same timeout of its own.
+  Zero means no timeout`)
+
 	fs.BoolVar(&o.CleanupOnExit, "cleanup-on-exit", true,
 		`When enabled, attempt to drop the maintenance task if the utility is killed by SIGTERM.`)
 
+	fs.BoolVar(&o.DropStaleRollingTasks, "drop-stale-rolling-tasks", false,
+		`Before restarting, drop maintenance tasks of the user left by previous rolling restarts
+  (with the 'rolling-restart-' prefix). Other tasks of the user are never dropped.`)
+
+	fs.BoolVar(&o.ExtendLocks, "extend-locks", false,
+		`Request the locks of granted nodes again when they would expire before the nodes are restarted.
+  CMS can not prolong a lock: the nodes, together with the nodes still waiting, are queued in
+  a follow-up task, and the current task is dropped once the nodes restarted now are done`)
+
+	fs.StringVar(&o.Order, "order", OrderCMS,
+		fmt.Sprintf(`The order to restart nodes in. Available choices: %s.
+  'cms' restarts nodes as soon as CMS allows. 'dc' and 'rack' finish one datacenter or rack
+  before requesting the next one. 'least-loaded' and 'oldest' prefer nodes with the lowest
+  load factor or the earliest start time`, strings.Join(OrderChoices, ", ")))
+
+	fs.BoolVar(&o.HostLevel, "host-level", false,
+		`Lock whole hosts instead of single nodes and restart every selected node of a host
+before completing its action: storage nodes first, then tenant nodes ordered by tenant and node id`)
+
+	fs.StringVar(&o.PreNodeHook, "pre-node-hook", "",
+		`Executable to run on the local machine before every node restart, e.g. to silence monitoring
+or take the node out of a load balancer. Gets the same environment as 'ydbops run' payloads`)
+
+	fs.StringVar(&o.PostNodeHook, "post-node-hook", "",
+		fmt.Sprintf(`Executable to run on the local machine after every node restart, even a failed one.
+Gets the same environment as --pre-node-hook, plus $%s ('%s' or '%s') and $%s`,
+			RestartResultEnvVar, resultOK, resultFailed, RestartErrorEnvVar))
+
+	fs.StringVar(&o.PreNodeHookFailure, "pre-node-hook-failure", HookFailureFail,
+		fmt.Sprintf(`What to do with a node if --pre-node-hook fails. Available choices: %s.
+  'fail' fails the restart attempt, 'skip' leaves the node as is, reports it as skipped and releases its lock`,
+			strings.Join(HookFailureChoices, ", ")))
+
+	fs.StringVar(&o.PreRunHook, "pre-run-hook", "",
+		"Executable to run on the local machine once before the restart. The restart is cancelled if it fails")
+
+	fs.StringVar(&o.PostRunHook, "post-run-hook", "",
+		fmt.Sprintf("Executable to run on the local machine once after the restart, even a failed one. Gets $%s and $%s",
+			RunResultEnvVar, RunErrorEnvVar))
+
+	o.Notify.DefineFlags(fs)
+
+	fs.StringVar(&o.PlanOut, "plan-out", "",
+		`Do not restart anything, write the restart plan to this YAML file instead: the nodes in the order
+  of restarts, inflight limits, availability mode, retries and timeouts, systemd units, ssh arguments,
+  restarters and hooks. Execute it with --plan`)
+
+	fs.StringVar(&o.PlanFile, "plan", "",
+		`Execute the restart plan written by --plan-out. Settings of the plan take precedence over flags,
+  node filters are ignored. Fails if planned nodes are gone or have changed their host or version`)
+
 	fs.IntVar(&o.TenantsInflight, "tenants-inflight", DefaultTenantsInflight,
 		`The number of tenants (databases) to restart concurrently. 
 Each tenant gets up to --nodes-inflight parallel restarts. 
 Default 0 means no grouping by tenant, restarting with global --nodes-inflight`)
 }
 
-func (o *RestartOptions) GetRestartDuration(nNodes int) *durationpb.Duration {
+// GetRestartDuration estimates how long nGroups action groups take to restart,
+// --nodes-inflight groups at a time.
+func (o *RestartOptions) GetRestartDuration(nGroups int) *durationpb.Duration {
 	singleBatchRestartTime := time.Second * time.Duration(o.RestartDuration) * time.Duration(o.RestartRetryNumber)
 	singleBatchWithWait := singleBatchRestartTime + o.DelayBetweenRestarts
-	maximumTotalBatches := int(math.Ceil(float64(nNodes) / float64(o.NodesInflight)))
+	maximumTotalBatches := int(math.Ceil(float64(nGroups) / float64(o.NodesInflight)))
 
 	finalDuration := time.Duration(maximumTotalBatches) * singleBatchWithWait
 	return durationpb.New(finalDuration)
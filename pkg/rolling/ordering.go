@@ -0,0 +1,153 @@
+package rolling
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+)
+
+const (
+	// OrderCMS restarts nodes in whatever order CMS grants them.
+	OrderCMS = "cms"
+	// OrderDatacenter restarts datacenters one after another, one maintenance task per datacenter.
+	OrderDatacenter = "dc"
+	// OrderRack restarts racks one after another, one maintenance task per rack.
+	OrderRack = "rack"
+	// OrderLeastLoaded restarts nodes with the lowest discovery load factor first.
+	OrderLeastLoaded = "least-loaded"
+	// OrderOldest restarts nodes with the earliest start time first.
+	OrderOldest = "oldest"
+)
+
+var OrderChoices = []string{OrderCMS, OrderDatacenter, OrderRack, OrderLeastLoaded, OrderOldest}
+
+// wave is a set of nodes that is restarted within one maintenance task.
+// The next wave is only submitted when the previous one is finished.
+type wave struct {
+	name  string
+	nodes []*Ydb_Maintenance.Node
+}
+
+// splitIntoWaves splits nodes by failure domain for --order dc and --order rack,
+// waves are sorted by name. Other orders restart all nodes in a single wave.
+func (r *Rolling) splitIntoWaves(nodes []*Ydb_Maintenance.Node) []wave {
+	var domainOf func(*Ydb_Maintenance.Node) string
+	switch r.opts.Order {
+	case OrderDatacenter:
+		domainOf = func(node *Ydb_Maintenance.Node) string {
+			return "datacenter " + node.GetLocation().GetDataCenter()
+		}
+	case OrderRack:
+		domainOf = func(node *Ydb_Maintenance.Node) string {
+			return "rack " + node.GetLocation().GetRack()
+		}
+	default:
+		return []wave{{name: "all nodes", nodes: nodes}}
+	}
+
+	byDomain := make(map[string][]*Ydb_Maintenance.Node)
+	for _, node := range nodes {
+		domain := domainOf(node)
+		byDomain[domain] = append(byDomain[domain], node)
+	}
+
+	waves := make([]wave, 0, len(byDomain))
+	for domain, domainNodes := range byDomain {
+		waves = append(waves, wave{name: domain, nodes: domainNodes})
+	}
+	sort.Slice(waves, func(i, j int) bool {
+		return waves[i].name < waves[j].name
+	})
+
+	return waves
+}
+
+// orderNodes sorts nodes for --order least-loaded and --order oldest and remembers
+// the resulting rank of every node. Action groups are submitted to CMS in this order,
+// and granted groups are dispatched in this order as well.
+func (r *Rolling) orderNodes(nodes []*Ydb_Maintenance.Node) error {
+	var less func(a, b *Ydb_Maintenance.Node) bool
+
+	switch r.opts.Order {
+	case OrderOldest:
+		less = func(a, b *Ydb_Maintenance.Node) bool {
+			return a.GetStartTime().AsTime().Before(b.GetStartTime().AsTime())
+		}
+	case OrderLeastLoaded:
+		loads, err := r.loadFactors(nodes)
+		if err != nil {
+			return err
+		}
+		less = func(a, b *Ydb_Maintenance.Node) bool {
+			return loads[a.GetNodeId()] < loads[b.GetNodeId()]
+		}
+	default:
+		return nil
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return less(nodes[i], nodes[j])
+	})
+
+	r.rankNodes(nodes)
+	return nil
+}
+
+// ranksNodes tells if granted groups are dispatched in the order of nodes, see orderNodes.
+func ranksNodes(order string) bool {
+	return order == OrderOldest || order == OrderLeastLoaded
+}
+
+func (r *Rolling) rankNodes(nodes []*Ydb_Maintenance.Node) {
+	r.state.nodeRank = make(map[uint32]int, len(nodes))
+	for i, node := range nodes {
+		r.state.nodeRank[node.GetNodeId()] = i
+	}
+}
+
+// loadFactors asks discovery for load factors of tenant nodes. Nodes that are not
+// reported, e.g. storage nodes, are considered idle.
+func (r *Rolling) loadFactors(nodes []*Ydb_Maintenance.Node) (map[uint32]float32, error) {
+	tenants := []string{}
+	for _, node := range nodes {
+		if tenant := node.GetDynamic().GetTenant(); tenant != "" && !slices.Contains(tenants, tenant) {
+			tenants = append(tenants, tenant)
+		}
+	}
+
+	loads := make(map[uint32]float32)
+	for _, tenant := range tenants {
+		endpoints, err := r.discovery.ListEndpoints(tenant)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list endpoints of tenant %s to determine load: %w", tenant, err)
+		}
+		for _, endpoint := range endpoints {
+			loads[endpoint.GetNodeId()] = endpoint.GetLoadFactor()
+		}
+	}
+
+	r.logger.Debugf("Node load factors: %v", loads)
+	return loads, nil
+}
+
+// sortByRank puts granted action groups into the order chosen by orderNodes.
+func (r *Rolling) sortByRank(groups []*Ydb_Maintenance.ActionGroupStates) {
+	if r.state.nodeRank == nil {
+		return
+	}
+
+	// a host is ranked by its best ranked node
+	rankOf := func(gs *Ydb_Maintenance.ActionGroupStates) int {
+		rank := math.MaxInt
+		for _, node := range r.nodesOf(gs.ActionStates[0].GetAction().GetLockAction().GetScope()) {
+			rank = min(rank, r.state.nodeRank[node.GetNodeId()])
+		}
+		return rank
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return rankOf(groups[i]) < rankOf(groups[j])
+	})
+}
@@ -0,0 +1,306 @@
+package rolling
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"gopkg.in/yaml.v2"
+
+	"github.com/ydb-platform/ydbops/internal/collections"
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+)
+
+// Plan is what a rolling restart is going to do, written by --plan-out for review
+// and executed as is by --plan. A restart consists of phases, one per restarter:
+// storage nodes and then tenant nodes, or a single phase with --host-level.
+type Plan struct {
+	CreatedAt time.Time `yaml:"createdAt"`
+
+	AvailabilityMode     string    `yaml:"availabilityMode"`
+	Storage              bool      `yaml:"storage"`
+	Tenant               bool      `yaml:"tenant"`
+	HostLevel            bool      `yaml:"hostLevel"`
+	Order                string    `yaml:"order"`
+	GroupBy              string    `yaml:"groupBy"`
+	GroupsFile           string    `yaml:"groupsFile,omitempty"`
+	NodesInflight        int       `yaml:"nodesInflight"`
+	TenantsInflight      int       `yaml:"tenantsInflight"`
+	DelayBetweenRestarts string    `yaml:"delayBetweenRestarts"`
+	RestartRetryNumber   int       `yaml:"restartRetryNumber"`
+	RestartDuration      int       `yaml:"restartDuration"`
+	MaxFailedNodes       int       `yaml:"maxFailedNodes"`
+	NodeRestartTimeout   string    `yaml:"nodeRestartTimeout"`
+	SystemdUnit          string    `yaml:"systemdUnit,omitempty"`
+	TenantSystemdUnit    string    `yaml:"tenantSystemdUnit,omitempty"`
+	SSHArgs              []string  `yaml:"sshArgs,omitempty"`
+	Hooks                PlanHooks `yaml:"hooks"`
+
+	Phases []PlanPhase `yaml:"phases"`
+
+	// nextPhase is the phase the next executer runs
+	nextPhase int
+}
+
+type PlanHooks struct {
+	PreRun             string `yaml:"preRun,omitempty"`
+	PostRun            string `yaml:"postRun,omitempty"`
+	PreNode            string `yaml:"preNode,omitempty"`
+	PostNode           string `yaml:"postNode,omitempty"`
+	PreNodeHookFailure string `yaml:"preNodeFailure"`
+}
+
+type PlanPhase struct {
+	Restarter string     `yaml:"restarter"`
+	Waves     []PlanWave `yaml:"waves"`
+}
+
+// PlanWave is restarted within one maintenance task, nodes are listed in the order of restarts.
+type PlanWave struct {
+	Name  string     `yaml:"name"`
+	Nodes []PlanNode `yaml:"nodes"`
+}
+
+// PlanNode is what the node is expected to be when the plan is executed.
+type PlanNode struct {
+	NodeID  uint32 `yaml:"nodeId"`
+	Host    string `yaml:"host"`
+	Tenant  string `yaml:"tenant,omitempty"`
+	Version string `yaml:"version"`
+}
+
+func newPlan(o *RestartOptions) *Plan {
+	return &Plan{
+		CreatedAt:            time.Now().UTC().Truncate(time.Second),
+		AvailabilityMode:     o.AvailabilityMode,
+		Storage:              o.Storage,
+		Tenant:               o.Tenant,
+		HostLevel:            o.HostLevel,
+		Order:                o.Order,
+		GroupBy:              o.GroupBy,
+		GroupsFile:           o.GroupsFile,
+		NodesInflight:        o.NodesInflight,
+		TenantsInflight:      o.TenantsInflight,
+		DelayBetweenRestarts: o.DelayBetweenRestarts.String(),
+		RestartRetryNumber:   o.RestartRetryNumber,
+		RestartDuration:      o.RestartDuration,
+		MaxFailedNodes:       o.MaxFailedNodes,
+		NodeRestartTimeout:   o.NodeRestartTimeout.String(),
+		SystemdUnit:          o.CustomSystemdUnitName,
+		TenantSystemdUnit:    o.TenantSystemdUnitName,
+		SSHArgs:              o.SSHArgs,
+		Hooks: PlanHooks{
+			PreRun:             o.PreRunHook,
+			PostRun:            o.PostRunHook,
+			PreNode:            o.PreNodeHook,
+			PostNode:           o.PostNodeHook,
+			PreNodeHookFailure: o.PreNodeHookFailure,
+		},
+	}
+}
+
+func LoadPlan(path string) (*Plan, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --plan: %w", err)
+	}
+
+	plan := &Plan{}
+	if err = yaml.UnmarshalStrict(content, plan); err != nil {
+		return nil, fmt.Errorf("failed to parse --plan %s: %w", path, err)
+	}
+	if len(plan.Phases) == 0 {
+		return nil, fmt.Errorf("failed to parse --plan %s: no restart phases found", path)
+	}
+	return plan, nil
+}
+
+func (p *Plan) Write(path string) error {
+	content, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to serialize the restart plan: %w", err)
+	}
+	if err = os.WriteFile(path, content, 0o640); err != nil {
+		return fmt.Errorf("failed to write --plan-out: %w", err)
+	}
+	return nil
+}
+
+// apply overrides the options with the settings of the plan, the plan is executed
+// the way it was made no matter what flags are given along with it.
+func (p *Plan) apply(o *RestartOptions) error {
+	delay, err := time.ParseDuration(p.DelayBetweenRestarts)
+	if err != nil {
+		return fmt.Errorf("specified invalid delayBetweenRestarts in --plan: %w", err)
+	}
+	restartTimeout, err := time.ParseDuration(p.NodeRestartTimeout)
+	if err != nil {
+		return fmt.Errorf("specified invalid nodeRestartTimeout in --plan: %w", err)
+	}
+
+	o.AvailabilityMode = p.AvailabilityMode
+	o.Storage = p.Storage
+	o.Tenant = p.Tenant
+	o.HostLevel = p.HostLevel
+	o.Order = p.Order
+	o.GroupBy = p.GroupBy
+	o.GroupsFile = p.GroupsFile
+	o.NodesInflight = p.NodesInflight
+	o.TenantsInflight = p.TenantsInflight
+	o.DelayBetweenRestarts = delay
+	o.RestartRetryNumber = p.RestartRetryNumber
+	o.RestartDuration = p.RestartDuration
+	o.MaxFailedNodes = p.MaxFailedNodes
+	o.NodeRestartTimeout = restartTimeout
+	o.CustomSystemdUnitName = p.SystemdUnit
+	o.TenantSystemdUnitName = p.TenantSystemdUnit
+	o.SSHArgs = p.SSHArgs
+	o.PreRunHook = p.Hooks.PreRun
+	o.PostRunHook = p.Hooks.PostRun
+	o.PreNodeHook = p.Hooks.PreNode
+	o.PostNodeHook = p.Hooks.PostNode
+	o.PreNodeHookFailure = p.Hooks.PreNodeHookFailure
+	return nil
+}
+
+// takePhase returns the phase to run with the restarter, phases are run in the order they were planned.
+func (p *Plan) takePhase(restarter string) (*PlanPhase, error) {
+	if p.nextPhase >= len(p.Phases) {
+		return nil, fmt.Errorf("the plan has %d restart phases, all of them are done, nothing is planned for %s",
+			len(p.Phases), restarter)
+	}
+
+	phase := &p.Phases[p.nextPhase]
+	if phase.Restarter != restarter {
+		return nil, fmt.Errorf("restart phase %d of the plan is planned for %s, not %s. Was the plan made by another command?",
+			p.nextPhase+1, phase.Restarter, restarter)
+	}
+	p.nextPhase++
+	return phase, nil
+}
+
+func restarterName(r restarters.Restarter) string {
+	t := reflect.TypeOf(r)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// writePlanPhase adds the nodes this executer would restart to --plan-out,
+// nothing is restarted. The file is rewritten by every executer of the run.
+func (r *Rolling) writePlanPhase() error {
+	state, err := r.prepareState()
+	if err != nil {
+		return err
+	}
+	r.state = state
+
+	waves, err := r.selectWaves()
+	if err != nil {
+		return err
+	}
+
+	phase := PlanPhase{Restarter: restarterName(r.restarter), Waves: []PlanWave{}}
+	planned := 0
+	for _, w := range waves {
+		pw := PlanWave{Name: w.name}
+		for _, node := range w.nodes {
+			pw.Nodes = append(pw.Nodes, PlanNode{
+				NodeID:  node.GetNodeId(),
+				Host:    node.GetHost(),
+				Tenant:  node.GetDynamic().GetTenant(),
+				Version: node.GetVersion(),
+			})
+		}
+		phase.Waves = append(phase.Waves, pw)
+		planned += len(w.nodes)
+	}
+
+	if r.opts.planOut == nil {
+		r.opts.planOut = newPlan(r.opts)
+	}
+	r.opts.planOut.Phases = append(r.opts.planOut.Phases, phase)
+	if err = r.opts.planOut.Write(r.opts.PlanOut); err != nil {
+		return err
+	}
+
+	r.logger.Infof("Planned %d nodes to restart with %s in %d waves, the plan is written to %s",
+		planned, phase.Restarter, len(waves), r.opts.PlanOut)
+	return nil
+}
+
+// replayPlanPhase returns the waves of the next phase of --plan, if the planned
+// nodes are still there the way they were when the plan was made.
+func (r *Rolling) replayPlanPhase() ([]wave, error) {
+	phase, err := r.opts.plan.takePhase(restarterName(r.restarter))
+	if err != nil {
+		return nil, err
+	}
+
+	drift := []string{}
+	planned := []uint32{}
+	for _, pw := range phase.Waves {
+		for _, pn := range pw.Nodes {
+			planned = append(planned, pn.NodeID)
+
+			node, ok := r.state.nodes[pn.NodeID]
+			switch {
+			case !ok:
+				drift = append(drift, fmt.Sprintf("node %d on %s is gone or not up", pn.NodeID, pn.Host))
+			case node.GetHost() != pn.Host:
+				drift = append(drift, fmt.Sprintf("node %d has moved from %s to %s", pn.NodeID, pn.Host, node.GetHost()))
+			case node.GetVersion() != pn.Version:
+				drift = append(drift, fmt.Sprintf("node %d on %s has changed its version from '%s' to '%s'",
+					pn.NodeID, pn.Host, pn.Version, node.GetVersion()))
+			}
+		}
+	}
+	if len(drift) > 0 {
+		return nil, fmt.Errorf("the cluster has changed since the plan was made, make a new plan: %s",
+			strings.Join(drift, "; "))
+	}
+
+	if len(planned) == 0 {
+		return nil, nil
+	}
+
+	// the restarter is given the planned nodes only, it may need to prepare for restarting them
+	selected := r.restarter.Filter(
+		restarters.FilterNodeParams{
+			SelectedNodeIds: planned,
+			MaxStaticNodeID: uint32(r.opts.MaxStaticNodeID),
+		},
+		restarters.ClusterNodesInfo{
+			TenantToNodeIds: r.state.tenantNameToNodeIds,
+			AllNodes:        collections.Values(r.state.nodes),
+		},
+	)
+	selectedIds := collections.ToMap(selected, func(n *Ydb_Maintenance.Node) uint32 { return n.NodeId })
+
+	waves := make([]wave, 0, len(phase.Waves))
+	ordered := make([]*Ydb_Maintenance.Node, 0, len(planned))
+	for _, pw := range phase.Waves {
+		w := wave{name: pw.Name}
+		for _, pn := range pw.Nodes {
+			if _, ok := selectedIds[pn.NodeID]; !ok {
+				return nil, fmt.Errorf("the cluster has changed since the plan was made, make a new plan: "+
+					"node %d on %s can not be restarted with %s any more", pn.NodeID, pn.Host, phase.Restarter)
+			}
+			w.nodes = append(w.nodes, r.state.nodes[pn.NodeID])
+		}
+		waves = append(waves, w)
+		ordered = append(ordered, w.nodes...)
+	}
+
+	if ranksNodes(r.opts.Order) {
+		r.rankNodes(ordered)
+	}
+	r.state.totalFilteredNodes = len(ordered)
+
+	r.logger.Infof("Restarting %d nodes with %s as planned", len(ordered), phase.Restarter)
+	return waves, nil
+}
@@ -0,0 +1,135 @@
+package rolling
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+)
+
+// selectingRestarter selects the nodes given by id, like restarters do.
+type selectingRestarter struct{}
+
+func (r selectingRestarter) Filter(spec restarters.FilterNodeParams, cluster restarters.ClusterNodesInfo) []*Ydb_Maintenance.Node {
+	selected := []*Ydb_Maintenance.Node{}
+	for _, node := range cluster.AllNodes {
+		if slices.Contains(spec.SelectedNodeIds, node.GetNodeId()) {
+			selected = append(selected, node)
+		}
+	}
+	return selected
+}
+
+func (r selectingRestarter) RestartNode(context.Context, *Ydb_Maintenance.Node) error {
+	return nil
+}
+
+var _ = Describe("Test restart plan", func() {
+	var (
+		rolling *Rolling
+		plan    *Plan
+	)
+
+	BeforeEach(func() {
+		plan = &Plan{
+			Phases: []PlanPhase{{
+				Restarter: "selectingRestarter",
+				Waves: []PlanWave{
+					{Name: "datacenter a", Nodes: []PlanNode{{NodeID: 2, Host: "ydb-2", Version: "24.1.1"}}},
+					{Name: "datacenter b", Nodes: []PlanNode{{NodeID: 1, Host: "ydb-1", Version: "24.1.1"}}},
+				},
+			}},
+		}
+		rolling = &Rolling{
+			logger:    zap.S(),
+			restarter: selectingRestarter{},
+			opts:      &RestartOptions{Order: OrderDatacenter, plan: plan},
+			state: &state{
+				nodes: map[uint32]*Ydb_Maintenance.Node{
+					1: {NodeId: 1, Host: "ydb-1", Version: "24.1.1"},
+					2: {NodeId: 2, Host: "ydb-2", Version: "24.1.1"},
+					3: {NodeId: 3, Host: "ydb-3", Version: "24.1.1"},
+				},
+			},
+		}
+	})
+
+	It("replays the planned waves in the planned order", func() {
+		waves, err := rolling.replayPlanPhase()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(waves).To(HaveLen(2))
+		Expect(waves[0].name).To(Equal("datacenter a"))
+		Expect(waves[0].nodes).To(ConsistOf(rolling.state.nodes[2]))
+		Expect(waves[1].name).To(Equal("datacenter b"))
+		Expect(waves[1].nodes).To(ConsistOf(rolling.state.nodes[1]))
+		Expect(rolling.state.totalFilteredNodes).To(Equal(2))
+	})
+
+	It("fails when planned nodes are gone or have changed", func() {
+		delete(rolling.state.nodes, 1)
+		rolling.state.nodes[2].Version = "24.2.1"
+
+		_, err := rolling.replayPlanPhase()
+		Expect(err).To(MatchError("the cluster has changed since the plan was made, make a new plan: " +
+			"node 2 on ydb-2 has changed its version from '24.1.1' to '24.2.1'; node 1 on ydb-1 is gone or not up"))
+	})
+
+	It("fails when the phase is planned for another restarter", func() {
+		plan.Phases[0].Restarter = "StorageK8sRestarter"
+
+		_, err := rolling.replayPlanPhase()
+		Expect(err).To(MatchError(
+			"restart phase 1 of the plan is planned for StorageK8sRestarter, not selectingRestarter. " +
+				"Was the plan made by another command?",
+		))
+	})
+
+	It("is read back with the settings it was written with", func() {
+		opts := &RestartOptions{
+			Order:                 OrderOldest,
+			NodesInflight:         3,
+			PreNodeHookFailure:    HookFailureSkip,
+			RestartRetryNumber:    1,
+			RestartDuration:       300,
+			MaxFailedNodes:        2,
+			NodeRestartTimeout:    10 * time.Minute,
+			CustomSystemdUnitName: "ydbd-storage.service",
+			TenantSystemdUnitName: "ydbd-{tenant}.service",
+			SSHArgs:               []string{"ssh", "-J", "jump-host"},
+		}
+		opts.AvailabilityMode = "weak"
+		opts.Storage = true
+
+		written := newPlan(opts)
+		written.Phases = plan.Phases
+		path := filepath.Join(GinkgoT().TempDir(), "plan.yaml")
+		Expect(written.Write(path)).To(Succeed())
+
+		read, err := LoadPlan(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read).To(Equal(written))
+
+		replayed := &RestartOptions{NodesInflight: 10, RestartRetryNumber: DefaultRetryCount, SSHArgs: []string{"ssh"}}
+		Expect(read.apply(replayed)).To(Succeed())
+		Expect(replayed.Order).To(Equal(OrderOldest))
+		Expect(replayed.NodesInflight).To(Equal(3))
+		Expect(replayed.AvailabilityMode).To(Equal("weak"))
+		Expect(replayed.Storage).To(BeTrue())
+		Expect(replayed.PreNodeHookFailure).To(Equal(HookFailureSkip))
+		Expect(replayed.RestartRetryNumber).To(Equal(1))
+		Expect(replayed.RestartDuration).To(Equal(300))
+		Expect(replayed.MaxFailedNodes).To(Equal(2))
+		Expect(replayed.NodeRestartTimeout).To(Equal(10 * time.Minute))
+		Expect(replayed.CustomSystemdUnitName).To(Equal("ydbd-storage.service"))
+		Expect(replayed.TenantSystemdUnitName).To(Equal("ydbd-{tenant}.service"))
+		Expect(replayed.SSHArgs).To(Equal([]string{"ssh", "-J", "jump-host"}))
+	})
+})
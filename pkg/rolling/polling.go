@@ -0,0 +1,42 @@
+package rolling
+
+import (
+	"time"
+)
+
+// pollPolicy decides how long to wait before refreshing a maintenance task.
+// The delay grows exponentially from min to max while nothing happens, and
+// is reset once nodes are restarted: CMS is likely to grant more right away.
+type pollPolicy struct {
+	min     time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+func newPollPolicy(minInterval, maxInterval time.Duration) *pollPolicy {
+	return &pollPolicy{
+		min:     minInterval,
+		max:     max(minInterval, maxInterval),
+		current: minInterval,
+	}
+}
+
+// progressed is called when restarted nodes have been reported to CMS,
+// the task is refreshed without waiting.
+func (p *pollPolicy) progressed() time.Duration {
+	p.current = p.min
+	return 0
+}
+
+// idle is called when nothing could be done. retryAfter is the hint
+// from CMS, zero if there is none; waiting less than that is pointless,
+// so it replaces the backoff, still within [min, max].
+func (p *pollPolicy) idle(retryAfter, now time.Time) time.Duration {
+	if !retryAfter.IsZero() {
+		return min(max(retryAfter.Sub(now), p.min), p.max)
+	}
+
+	delay := p.current
+	p.current = min(max(2*p.current, time.Second), p.max)
+	return delay
+}
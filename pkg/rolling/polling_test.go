@@ -0,0 +1,53 @@
+package rolling
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test CMS polling", func() {
+	var (
+		poll *pollPolicy
+		now  time.Time
+	)
+
+	BeforeEach(func() {
+		poll = newPollPolicy(2*time.Second, 20*time.Second)
+		now = time.Now()
+	})
+
+	It("backs off exponentially up to the max interval while nothing changes", func() {
+		delays := []time.Duration{}
+		for i := 0; i < 6; i++ {
+			delays = append(delays, poll.idle(time.Time{}, now))
+		}
+		Expect(delays).To(Equal([]time.Duration{
+			2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 20 * time.Second, 20 * time.Second,
+		}))
+	})
+
+	It("refreshes right away after progress and starts over", func() {
+		poll.idle(time.Time{}, now)
+		poll.idle(time.Time{}, now)
+
+		Expect(poll.progressed()).To(BeZero())
+		Expect(poll.idle(time.Time{}, now)).To(Equal(2 * time.Second))
+	})
+
+	It("honors retry-after within the interval bounds", func() {
+		Expect(poll.idle(now.Add(7*time.Second), now)).To(Equal(7 * time.Second))
+		Expect(poll.idle(now.Add(time.Hour), now)).To(Equal(20 * time.Second))
+		Expect(poll.idle(now.Add(-time.Minute), now)).To(Equal(2 * time.Second))
+
+		// retry-after does not advance the backoff
+		Expect(poll.idle(time.Time{}, now)).To(Equal(2 * time.Second))
+	})
+
+	It("never uses a max interval below the min interval", func() {
+		poll = newPollPolicy(30*time.Second, 10*time.Second)
+		Expect(poll.idle(time.Time{}, now)).To(Equal(30 * time.Second))
+		Expect(poll.idle(time.Time{}, now)).To(Equal(30 * time.Second))
+	})
+})
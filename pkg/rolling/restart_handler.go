@@ -2,6 +2,7 @@ package rolling
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -13,9 +14,10 @@ import (
 )
 
 type restartStatus struct {
-	nodeID uint32
-	as     *Ydb_Maintenance.ActionState
-	err    error
+	as  *Ydb_Maintenance.ActionState
+	err error
+	// skipped nodes of the action were left as is, see --pre-node-hook-failure
+	skipped int
 }
 
 type restartHandler struct {
@@ -23,15 +25,21 @@ type restartHandler struct {
 	logger    *zap.SugaredLogger
 	queue     chan *Ydb_Maintenance.ActionGroupStates
 	restarter restarters.Restarter
+	hooks     *nodeHooks
 	statusCh  chan<- restartStatus
 
-	// TODO(shmel1k@): probably, not needed here.
-	nodes map[uint32]*Ydb_Maintenance.Node
+	nodesOf   func(*Ydb_Maintenance.ActionScope) []*Ydb_Maintenance.Node
+	attemptOf func(*Ydb_Maintenance.ActionState) restarters.RestartAttempt
 
 	wg sync.WaitGroup
+	// slots limit the actions restarted at once to nodesInflight, also
+	// within one action group
+	slots chan struct{}
 
 	nodesInflight        int
 	delayBetweenRestarts time.Duration
+	// nodeRestartTimeout kills a hung restart, zero means no timeout
+	nodeRestartTimeout time.Duration
 }
 
 func (rh *restartHandler) push(state *Ydb_Maintenance.ActionGroupStates) {
@@ -57,26 +65,7 @@ func (rh *restartHandler) run() {
 						return
 					}
 
-					as := gs.ActionStates[0]
-					lock := as.Action.GetLockAction()
-					if lock == nil {
-						panic(fmt.Sprintf("unexpected non-lock action type in restartHandler: %v", as.Action))
-					}
-					node := rh.nodes[lock.Scope.GetNodeId()]
-
-					rh.logger.Debugf("Restart node with id: %d", node.GetNodeId())
-
-					// TODO(shmel1k@): draining should be implemented in RestartNode.
-					rh.logger.Debugf("Drain node with id: %d", node.GetNodeId())
-					// TODO: drain node, but public draining api is not available yet
-					rh.logger.Info("DRAINING NOT IMPLEMENTED YET")
-
-					err := rh.restarter.RestartNode(node)
-					rh.statusCh <- restartStatus{
-						nodeID: lock.Scope.GetNodeId(),
-						as:     as,
-						err:    err,
-					}
+					rh.restartGroup(gs)
 
 					select {
 					case <-rh.ctx.Done():
@@ -90,6 +79,111 @@ func (rh *restartHandler) run() {
 	}
 }
 
+// restartGroup restarts the actions of an action group, CMS has granted them
+// together. No more than nodesInflight actions are restarted at once, so a
+// large group is restarted in several steps while it stays locked. Nodes
+// locked by one action, e.g. all nodes of a host, are restarted one after
+// another.
+func (rh *restartHandler) restartGroup(gs *Ydb_Maintenance.ActionGroupStates) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for _, as := range gs.ActionStates {
+		lock := as.Action.GetLockAction()
+		if lock == nil {
+			panic(fmt.Sprintf("unexpected non-lock action type in restartHandler: %v", as.Action))
+		}
+
+		select {
+		case <-rh.ctx.Done():
+			return
+		case rh.slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(as *Ydb_Maintenance.ActionState, scope *Ydb_Maintenance.ActionScope) {
+			defer wg.Done()
+			defer func() { <-rh.slots }()
+
+			var (
+				err     error
+				skipped int
+			)
+			attempt := rh.attemptOf(as)
+			for _, node := range rh.nodesOf(scope) {
+				err = rh.restartNode(node, attempt)
+				if errors.Is(err, errNodeSkipped) {
+					err = nil
+					skipped++
+					continue
+				}
+				if err != nil {
+					break
+				}
+			}
+
+			// statuses are not read any more after cancellation,
+			// and a group can have more nodes than the channel buffer
+			select {
+			case <-rh.ctx.Done():
+			case rh.statusCh <- restartStatus{
+				as:      as,
+				err:     err,
+				skipped: skipped,
+			}:
+			}
+		}(as, lock.Scope)
+	}
+}
+
+// restartNode returns errNodeSkipped if the pre-node hook has asked to leave
+// the node as is.
+func (rh *restartHandler) restartNode(node *Ydb_Maintenance.Node, attempt restarters.RestartAttempt) error {
+	restartCtx, cancel := rh.restartContext()
+	defer cancel()
+
+	if err := rh.hooks.before(restartCtx, node, attempt); err != nil {
+		return err
+	}
+
+	rh.logger.Debugf("Restart node with id: %d", node.GetNodeId())
+
+	// TODO(shmel1k@): draining should be implemented in RestartNode.
+	rh.logger.Debugf("Drain node with id: %d", node.GetNodeId())
+	// TODO: drain node, but public draining api is not available yet
+	rh.logger.Info("DRAINING NOT IMPLEMENTED YET")
+
+	var err error
+	if restartErr := restarters.RestartNodeAttempt(restartCtx, rh.restarter, node, attempt); restartErr != nil {
+		err = fmt.Errorf("failed to restart node %d: %w", node.GetNodeId(), restartErr)
+	}
+
+	// the post-node hook must run even after the restart has timed out
+	hookCtx, cancelHook := rh.restartContext()
+	defer cancelHook()
+
+	if hookErr := rh.hooks.after(hookCtx, node, attempt, err); hookErr != nil {
+		return errors.Join(err, hookErr)
+	}
+	return err
+}
+
+// restartContext limits a single node restart, together with its pre-node
+// hook, by --node-restart-timeout. The post-node hook gets a timeout of its
+// own. When it expires, the restarter or the hook kills whatever it has
+// started and the attempt fails like any other, so the slot is freed for
+// the next node.
+func (rh *restartHandler) restartContext() (context.Context, context.CancelFunc) {
+	if rh.nodeRestartTimeout <= 0 {
+		return context.WithCancel(rh.ctx)
+	}
+	return context.WithTimeoutCause(
+		rh.ctx,
+		rh.nodeRestartTimeout,
+		fmt.Errorf("restart did not finish in %s", rh.nodeRestartTimeout),
+	)
+}
+
 func (rh *restartHandler) stop(waitForDelay bool) {
 	close(rh.queue)
 	if waitForDelay {
@@ -101,19 +195,26 @@ func newRestartHandler(
 	ctx context.Context,
 	logger *zap.SugaredLogger,
 	restarter restarters.Restarter,
+	hooks *nodeHooks,
 	nodesInflight int,
 	delayBetweenRestarts time.Duration,
-	nodes map[uint32]*Ydb_Maintenance.Node,
+	nodeRestartTimeout time.Duration,
+	nodesOf func(*Ydb_Maintenance.ActionScope) []*Ydb_Maintenance.Node,
+	attemptOf func(*Ydb_Maintenance.ActionState) restarters.RestartAttempt,
 	statusCh chan<- restartStatus,
 ) *restartHandler {
 	return &restartHandler{
 		ctx:                  ctx,
 		logger:               logger,
 		restarter:            restarter,
+		hooks:                hooks,
 		queue:                make(chan *Ydb_Maintenance.ActionGroupStates),
+		slots:                make(chan struct{}, nodesInflight),
 		statusCh:             statusCh,
 		nodesInflight:        nodesInflight,
-		nodes:                nodes,
+		nodesOf:              nodesOf,
+		attemptOf:            attemptOf,
 		delayBetweenRestarts: delayBetweenRestarts,
+		nodeRestartTimeout:   nodeRestartTimeout,
 	}
 }
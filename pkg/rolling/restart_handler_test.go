@@ -0,0 +1,163 @@
+package rolling
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+)
+
+// hangingRestarter hangs on the nodes in hangOn until the restart is given up.
+type hangingRestarter struct {
+	hangOn map[uint32]bool
+}
+
+func (r hangingRestarter) Filter(restarters.FilterNodeParams, restarters.ClusterNodesInfo) []*Ydb_Maintenance.Node {
+	return nil
+}
+
+func (r hangingRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	if !r.hangOn[node.GetNodeId()] {
+		return nil
+	}
+	<-ctx.Done()
+	return context.Cause(ctx)
+}
+
+// countingRestarter remembers how many nodes it has restarted at once at most.
+type countingRestarter struct {
+	mu       sync.Mutex
+	current  int
+	maxSeen  int
+	duration time.Duration
+}
+
+func (r *countingRestarter) Filter(restarters.FilterNodeParams, restarters.ClusterNodesInfo) []*Ydb_Maintenance.Node {
+	return nil
+}
+
+func (r *countingRestarter) RestartNode(context.Context, *Ydb_Maintenance.Node) error {
+	r.mu.Lock()
+	r.current++
+	r.maxSeen = max(r.maxSeen, r.current)
+	r.mu.Unlock()
+
+	time.Sleep(r.duration)
+
+	r.mu.Lock()
+	r.current--
+	r.mu.Unlock()
+	return nil
+}
+
+var _ = Describe("Test restart handler", func() {
+	lockActionState := func(nodeID uint32) *Ydb_Maintenance.ActionGroupStates {
+		return &Ydb_Maintenance.ActionGroupStates{
+			ActionStates: []*Ydb_Maintenance.ActionState{{
+				Action: &Ydb_Maintenance.Action{
+					Action: &Ydb_Maintenance.Action_LockAction{
+						LockAction: &Ydb_Maintenance.LockAction{
+							Scope: &Ydb_Maintenance.ActionScope{
+								Scope: &Ydb_Maintenance.ActionScope_NodeId{NodeId: nodeID},
+							},
+						},
+					},
+				},
+			}},
+		}
+	}
+
+	groupState := func(nodeIDs ...uint32) *Ydb_Maintenance.ActionGroupStates {
+		gs := &Ydb_Maintenance.ActionGroupStates{}
+		for _, nodeID := range nodeIDs {
+			gs.ActionStates = append(gs.ActionStates, lockActionState(nodeID).ActionStates...)
+		}
+		return gs
+	}
+
+	newHandler := func(restarter restarters.Restarter, opts *RestartOptions, statusCh chan<- restartStatus) *restartHandler {
+		return newRestartHandler(
+			context.Background(),
+			zap.S(),
+			restarter,
+			&nodeHooks{logger: zap.S(), opts: opts},
+			1,
+			0,
+			100*time.Millisecond,
+			func(scope *Ydb_Maintenance.ActionScope) []*Ydb_Maintenance.Node {
+				return []*Ydb_Maintenance.Node{{NodeId: scope.GetNodeId()}}
+			},
+			func(*Ydb_Maintenance.ActionState) restarters.RestartAttempt {
+				return restarters.RestartAttempt{}
+			},
+			statusCh,
+		)
+	}
+
+	It("gives up on a hung restart after --node-restart-timeout and goes on", func() {
+		statusCh := make(chan restartStatus, 2)
+		handler := newHandler(hangingRestarter{hangOn: map[uint32]bool{1: true}}, &RestartOptions{}, statusCh)
+		handler.run()
+
+		handler.push(lockActionState(1))
+		handler.push(lockActionState(2))
+		handler.stop(true)
+
+		Expect((<-statusCh).err).To(MatchError("failed to restart node 1: restart did not finish in 100ms"))
+		Expect((<-statusCh).err).ToNot(HaveOccurred())
+	})
+
+	It("kills a hung pre-node hook after --node-restart-timeout", func() {
+		hook := filepath.Join(GinkgoT().TempDir(), "hook.sh")
+		Expect(os.WriteFile(hook, []byte("#!/bin/sh\nsleep 60\n"), 0o755)).To(Succeed())
+
+		statusCh := make(chan restartStatus, 1)
+		handler := newHandler(hangingRestarter{}, &RestartOptions{PreNodeHook: hook}, statusCh)
+		handler.run()
+
+		handler.push(lockActionState(1))
+		handler.stop(true)
+
+		Expect((<-statusCh).err).To(MatchError(
+			"pre-node hook failed for node 1: hook was killed: restart did not finish in 100ms",
+		))
+	})
+
+	It("reports a node skipped by --pre-node-hook-failure skip as skipped", func() {
+		statusCh := make(chan restartStatus, 1)
+		handler := newHandler(
+			hangingRestarter{},
+			&RestartOptions{PreNodeHook: "/bin/false", PreNodeHookFailure: HookFailureSkip},
+			statusCh,
+		)
+		handler.run()
+
+		handler.push(lockActionState(1))
+		handler.stop(true)
+
+		st := <-statusCh
+		Expect(st.err).ToNot(HaveOccurred())
+		Expect(st.skipped).To(Equal(1))
+	})
+
+	It("restarts no more than --nodes-inflight nodes of a large group at once", func() {
+		restarter := &countingRestarter{duration: 10 * time.Millisecond}
+		statusCh := make(chan restartStatus, 4)
+		handler := newHandler(restarter, &RestartOptions{}, statusCh)
+		handler.run()
+
+		handler.push(groupState(1, 2, 3, 4))
+		handler.stop(true)
+
+		Expect(statusCh).To(HaveLen(4))
+		Expect(restarter.maxSeen).To(Equal(1))
+	})
+})
@@ -95,7 +95,7 @@ func (r *k8sRestarter) prepareK8sState(kubeconfigPath, labelSelector, namespace
 	}
 }
 
-func (r *k8sRestarter) restartNodeByRestartingPod(nodeFQDN string, icPort uint32, namespace string) error {
+func (r *k8sRestarter) restartNodeByRestartingPod(ctx context.Context, nodeFQDN string, icPort uint32, namespace string) error {
 	podName, present := r.FQDNToPodName[fmt.Sprintf("%s:%d", nodeFQDN, icPort)]
 	if !present {
 		podName, present = r.FQDNToPodName[nodeFQDN]
@@ -109,7 +109,7 @@ func (r *k8sRestarter) restartNodeByRestartingPod(nodeFQDN string, icPort uint32
 
 	r.logger.Infof("Restarting pod %s on the %s node", podName, nodeFQDN)
 
-	pod, err := r.k8sClient.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	pod, err := r.k8sClient.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("pod scheduled for deletion %s not found: %w", podName, err)
 	}
@@ -118,7 +118,7 @@ func (r *k8sRestarter) restartNodeByRestartingPod(nodeFQDN string, icPort uint32
 	r.logger.Debugf("Pod %s id: %v", podName, oldUID)
 
 	err = r.k8sClient.CoreV1().Pods(namespace).Delete(
-		context.TODO(),
+		ctx,
 		podName,
 		metav1.DeleteOptions{},
 	)
@@ -1,9 +1,12 @@
 package restarters
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
@@ -19,8 +22,36 @@ const (
 	sshBin                    = "ssh"
 	psshBin                   = "pssh"
 	nsshBin                   = "nssh"
+
+	// killedOutputWaitDelay limits how long the output of a killed ssh is read:
+	// processes it has left behind may keep the pipes open.
+	killedOutputWaitDelay = 5 * time.Second
 )
 
+// hostLocks serializes changes to files that the nodes of a host share, such as
+// the ydbd link or the config file, when nodes of a host are restarted in parallel.
+type hostLocks struct {
+	mu    sync.Mutex
+	hosts map[string]*sync.Mutex
+}
+
+// lock locks the host and returns the function that unlocks it.
+func (l *hostLocks) lock(host string) func() {
+	l.mu.Lock()
+	if l.hosts == nil {
+		l.hosts = make(map[string]*sync.Mutex)
+	}
+	hostMu, ok := l.hosts[host]
+	if !ok {
+		hostMu = &sync.Mutex{}
+		l.hosts[host] = hostMu
+	}
+	l.mu.Unlock()
+
+	hostMu.Lock()
+	return hostMu.Unlock
+}
+
 func (r sshRestarter) stripCommandFromArgs(args []string) (string, []string) {
 	remainingSSHArgs := []string{}
 	command := sshBin
@@ -36,6 +67,7 @@ func (r sshRestarter) stripCommandFromArgs(args []string) (string, []string) {
 }
 
 func (r sshRestarter) restartNodeBySystemdUnit(
+	ctx context.Context,
 	node *Ydb_Maintenance.Node,
 	unitName string,
 	sshArgs []string,
@@ -43,19 +75,38 @@ func (r sshRestarter) restartNodeBySystemdUnit(
 	r.logger.Debugf("Restarting %s systemd unit", unitName)
 
 	remoteRestartCommand := fmt.Sprintf(
-		`"(test -x /bin/systemctl && sudo systemctl restart %s)"`,
+		"test -x /bin/systemctl && sudo systemctl restart %s",
 		unitName,
 	)
 
+	return r.runRemoteCommand(ctx, node.Host, remoteRestartCommand, sshArgs, nil, nil, nil)
+}
+
+// runRemoteCommand runs a shell command on the host. The command must not
+// contain double quotes or '$', it is passed to the remote side in double quotes.
+// stdin, if not nil, is streamed into the remote command. stdout and stderr,
+// if not nil, receive the output of the command instead of the log.
+// Once ctx is done, ssh is killed and the command fails.
+func (r sshRestarter) runRemoteCommand(
+	ctx context.Context,
+	host string,
+	remoteCommand string,
+	sshArgs []string,
+	stdin io.Reader,
+	stdout io.Writer,
+	stderr io.Writer,
+) error {
+	quotedRemoteCommand := fmt.Sprintf(`"(%s)"`, remoteCommand)
+
 	sshCommand, remainingSSHArgs := r.stripCommandFromArgs(sshArgs)
 
 	fullSSHArgs := []string{}
 	fullSSHArgs = append(fullSSHArgs, remainingSSHArgs...)
 	switch sshCommand {
 	case sshBin:
-		fullSSHArgs = append(fullSSHArgs, node.Host, remoteRestartCommand)
+		fullSSHArgs = append(fullSSHArgs, host, quotedRemoteCommand)
 	case nsshBin, psshBin:
-		fullSSHArgs = append(fullSSHArgs, "run", remoteRestartCommand, node.Host)
+		fullSSHArgs = append(fullSSHArgs, "run", quotedRemoteCommand, host)
 	default:
 		return fmt.Errorf("supported ssh commands: ssh, pssh, nssh. Specified: %s", sshCommand)
 	}
@@ -65,16 +116,31 @@ func (r sshRestarter) restartNodeBySystemdUnit(
 		return err
 	}
 
-	cmd := exec.Command(
+	// bash is replaced with ssh, so that ssh itself is killed once ctx is done
+	cmd := exec.CommandContext(
+		ctx,
 		bashPath,
 		"-c",
-		sshCommand+" "+strings.Join(fullSSHArgs, " "),
+		"exec "+sshCommand+" "+strings.Join(fullSSHArgs, " "),
 	)
 
+	cmd.Stdin = stdin
+	cmd.WaitDelay = killedOutputWaitDelay
+
 	r.logger.Debugf("Full ssh command: `%s %v`", sshCommand, strings.Join(fullSSHArgs, " "))
 
-	stdout, _ := cmd.StdoutPipe()
-	stderr, _ := cmd.StderrPipe()
+	var stdoutPipe io.ReadCloser
+	if stdout != nil {
+		cmd.Stdout = stdout
+	} else {
+		stdoutPipe, _ = cmd.StdoutPipe()
+	}
+	var stderrPipe io.ReadCloser
+	if stderr != nil {
+		cmd.Stderr = stderr
+	} else {
+		stderrPipe, _ = cmd.StderrPipe()
+	}
 
 	warningTime := 5 * time.Second
 	ticker := time.NewTicker(warningTime)
@@ -91,10 +157,17 @@ func (r sshRestarter) restartNodeBySystemdUnit(
 		return err
 	}
 
-	go StreamPipeIntoLogger(stdout, r.logger)
-	go StreamPipeIntoLogger(stderr, r.logger)
+	if stdoutPipe != nil {
+		go StreamPipeIntoLogger(stdoutPipe, r.logger)
+	}
+	if stderrPipe != nil {
+		go StreamPipeIntoLogger(stderrPipe, r.logger)
+	}
 
 	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("remote command was killed: %w", context.Cause(ctx))
+		}
 		r.logger.Errorf("Remote command finished with an error:", err)
 		return err
 	}
@@ -102,6 +175,10 @@ func (r sshRestarter) restartNodeBySystemdUnit(
 	return nil
 }
 
+// remoteRunner runs a command on a host, see sshRestarter.runRemoteCommand.
+// Restarters keep it in a field to replace ssh in tests.
+type remoteRunner func(ctx context.Context, host, command string, stdin io.Reader, stdout io.Writer) error
+
 func newSSHRestarter(logger *zap.SugaredLogger) sshRestarter {
 	return sshRestarter{
 		logger: logger,
@@ -0,0 +1,173 @@
+package restarters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ydb-platform/ydbops/pkg/utils"
+)
+
+// ConfigMap is the k8s ConfigMap ydb pods read the configuration from.
+// Pods only read it on start, so the map is updated once and the pods
+// pick the new configuration up as they are restarted one by one.
+// The map is shared by all pods: once it is restored after a failed pod,
+// the rollout is aborted and the map is not updated again.
+type ConfigMap struct {
+	k8sRestarter
+
+	kubeconfigPath string
+	namespace      string
+	name           string
+	key            string
+
+	mu       sync.Mutex
+	original *string
+	// aborted is why the rollout was aborted
+	aborted error
+}
+
+func NewConfigMap(logger *zap.SugaredLogger, kubeconfigPath, namespace, name, key string) *ConfigMap {
+	return &ConfigMap{
+		k8sRestarter:   newK8sRestarter(logger, &k8sRestarterOptions{}),
+		kubeconfigPath: kubeconfigPath,
+		namespace:      namespace,
+		name:           name,
+		key:            key,
+	}
+}
+
+// apply puts the content into the ConfigMap, unless it is already there, and
+// returns the diff with the previous content.
+func (c *ConfigMap) apply(content string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.aborted != nil {
+		return "", fmt.Errorf("the rollout of ConfigMap %s is aborted (%w): %w", c.name, ErrNotRetryable, c.aborted)
+	}
+
+	if c.k8sClient == nil {
+		c.k8sClient = c.createK8sClient(c.kubeconfigPath)
+	}
+
+	current, err := c.read()
+	if err != nil {
+		return "", err
+	}
+	if current == content {
+		return "", nil
+	}
+
+	if c.original == nil {
+		c.original = &current
+	}
+	return utils.LineDiff(current, content), c.write(content)
+}
+
+// restore puts back the content the ConfigMap had before the first apply
+// and aborts the rollout because of cause. Only the first call restores the map.
+func (c *ConfigMap) restore(cause error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.aborted != nil {
+		return nil
+	}
+	c.aborted = cause
+
+	if c.original == nil {
+		return nil
+	}
+	return c.write(*c.original)
+}
+
+func (c *ConfigMap) read() (string, error) {
+	cm, err := c.k8sClient.CoreV1().ConfigMaps(c.namespace).Get(context.TODO(), c.name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get ConfigMap %s: %w", c.name, err)
+	}
+
+	content, present := cm.Data[c.key]
+	if !present {
+		return "", fmt.Errorf("ConfigMap %s has no key %s", c.name, c.key)
+	}
+	return content, nil
+}
+
+func (c *ConfigMap) write(content string) error {
+	cm, err := c.k8sClient.CoreV1().ConfigMaps(c.namespace).Get(context.TODO(), c.name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s: %w", c.name, err)
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[c.key] = content
+	if _, err = c.k8sClient.CoreV1().ConfigMaps(c.namespace).Update(context.TODO(), cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s: %w", c.name, err)
+	}
+	return nil
+}
+
+// ConfigK8sRestarter updates the ConfigMap before restarting a pod with the wrapped
+// restarter, and restores the ConfigMap if the node does not come back.
+type ConfigK8sRestarter struct {
+	Opts *ConfigK8sOpts
+
+	logger    *zap.SugaredLogger
+	restarter Restarter
+}
+
+func NewConfigK8sRestarter(logger *zap.SugaredLogger, restarter Restarter, opts *ConfigK8sOpts) *ConfigK8sRestarter {
+	return &ConfigK8sRestarter{
+		Opts:      opts,
+		logger:    logger,
+		restarter: restarter,
+	}
+}
+
+func (r *ConfigK8sRestarter) Filter(spec FilterNodeParams, cluster ClusterNodesInfo) []*Ydb_Maintenance.Node {
+	return r.restarter.Filter(spec, cluster)
+}
+
+func (r *ConfigK8sRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	configMap := r.Opts.ConfigMap
+
+	diff, err := configMap.apply(string(r.Opts.Content))
+	if err != nil {
+		return err
+	}
+	if diff != "" {
+		r.logger.Infof("Config changes in ConfigMap %s:\n%s", configMap.name, diff)
+	}
+
+	err = r.restarter.RestartNode(ctx, node)
+	if err == nil {
+		err = waitUntil(ctx, r.Opts.ReadyTimeout, r.Opts.ReadyPollInterval, func() error {
+			return r.Opts.Ready(node)
+		})
+	}
+	if err == nil {
+		return nil
+	}
+
+	r.logger.Warnf("Node %d failed to come back with the new config, restoring ConfigMap %s and aborting the rollout: %v",
+		node.NodeId, configMap.name, err)
+	err = fmt.Errorf("node %d failed with the new config (%w): %w", node.NodeId, ErrNotRetryable, err)
+	if rollbackErr := configMap.restore(err); rollbackErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to restore the previous config: %w", rollbackErr))
+	}
+	rollbackCtx, cancel := rollbackContext(ctx)
+	defer cancel()
+	if rollbackErr := r.restarter.RestartNode(rollbackCtx, node); rollbackErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to restart the node with the previous config: %w", rollbackErr))
+	}
+	return err
+}
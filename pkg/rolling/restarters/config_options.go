@@ -0,0 +1,33 @@
+package restarters
+
+import (
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+)
+
+type ConfigOpts struct {
+	// Content is the new configuration file
+	Content []byte
+
+	ReadyTimeout      time.Duration
+	ReadyPollInterval time.Duration
+
+	// Ready tells if the node has come back after a restart. It receives the
+	// node as it was before the restart
+	Ready func(node *Ydb_Maintenance.Node) error
+}
+
+type ConfigSSHOpts struct {
+	sshOpts
+	ConfigOpts
+
+	// Path is the configuration file on the nodes
+	Path string
+}
+
+type ConfigK8sOpts struct {
+	ConfigOpts
+
+	ConfigMap *ConfigMap
+}
@@ -0,0 +1,201 @@
+package restarters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/pkg/utils"
+)
+
+const (
+	DefaultConfigPath = "/opt/ydb/cfg/config.yaml"
+)
+
+// ConfigSSHRestarter replaces the configuration file on a node before restarting
+// it with the wrapped restarter, and restores the previous file if the node does
+// not come back.
+type ConfigSSHRestarter struct {
+	sshRestarter
+
+	Opts *ConfigSSHOpts
+
+	restarter Restarter
+	runRemote remoteRunner
+	diffs     *diffReporter
+	hosts     hostLocks
+	// backedUp are the hosts whose config has been saved as '.previous' in this run,
+	// the saved config is the one to restore, it is never overwritten
+	backedUp sync.Map
+
+	mu sync.Mutex
+	// aborted is why the rollout was aborted
+	aborted error
+}
+
+func NewConfigSSHRestarter(logger *zap.SugaredLogger, sshArgs []string, restarter Restarter, opts *ConfigSSHOpts) *ConfigSSHRestarter {
+	opts.sshOpts = sshOpts{
+		sshArgs: sshArgs,
+	}
+	if opts.Path == "" {
+		opts.Path = DefaultConfigPath
+	}
+
+	r := &ConfigSSHRestarter{
+		Opts:         opts,
+		restarter:    restarter,
+		sshRestarter: newSSHRestarter(logger),
+		diffs:        &diffReporter{},
+	}
+	r.runRemote = func(ctx context.Context, host, command string, stdin io.Reader, stdout io.Writer) error {
+		return r.runRemoteCommand(ctx, host, command, r.Opts.sshArgs, stdin, stdout, nil)
+	}
+	return r
+}
+
+func (r *ConfigSSHRestarter) Filter(spec FilterNodeParams, cluster ClusterNodesInfo) []*Ydb_Maintenance.Node {
+	return r.restarter.Filter(spec, cluster)
+}
+
+func (r *ConfigSSHRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	path := r.Opts.Path
+
+	if err := r.abortedErr(); err != nil {
+		return err
+	}
+
+	replaced, err := r.replaceConfig(ctx, node.Host)
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		r.logger.Infof("%s on %s is up to date, only restarting the node", path, node.Host)
+		return r.restart(ctx, node)
+	}
+
+	err = r.restart(ctx, node)
+	if err == nil {
+		return nil
+	}
+
+	r.logger.Warnf("Node %d failed to come back with the new config, restoring %s and aborting the rollout: %v",
+		node.NodeId, path, err)
+	err = fmt.Errorf("node %d failed with the new config (%w): %w", node.NodeId, ErrNotRetryable, err)
+	r.abort(err)
+	rollbackCtx, cancel := rollbackContext(ctx)
+	defer cancel()
+	if rollbackErr := r.rollback(rollbackCtx, node); rollbackErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to restore the previous config: %w", rollbackErr))
+	}
+	return err
+}
+
+// abort stops the rollout because of cause, the nodes that have not been
+// restarted yet keep the previous config. Only the first cause is kept.
+func (r *ConfigSSHRestarter) abort(cause error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.aborted == nil {
+		r.aborted = cause
+	}
+}
+
+func (r *ConfigSSHRestarter) abortedErr() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.aborted != nil {
+		return fmt.Errorf("the rollout of %s is aborted (%w): %w", r.Opts.Path, ErrNotRetryable, r.aborted)
+	}
+	return nil
+}
+
+// replaceConfig puts the new config on the host, unless it is already there.
+// Nodes of a host share the config, it is replaced for one node at a time.
+func (r *ConfigSSHRestarter) replaceConfig(ctx context.Context, host string) (bool, error) {
+	defer r.hosts.lock(host)()
+
+	path := r.Opts.Path
+
+	var current bytes.Buffer
+	if err := r.runRemote(ctx, host, "sudo cat "+path, nil, &current); err != nil {
+		return false, fmt.Errorf("failed to fetch %s from %s: %w", path, host, err)
+	}
+
+	diff := utils.LineDiff(current.String(), string(r.Opts.Content))
+	if diff == "" {
+		return false, nil
+	}
+	r.diffs.report(r.logger, host, diff)
+
+	if err := r.runRemote(ctx, host, fmt.Sprintf("sudo tee %s > /dev/null", path+newSuffix), bytes.NewReader(r.Opts.Content), nil); err != nil {
+		return false, fmt.Errorf("failed to upload %s to %s: %w", path, host, err)
+	}
+
+	installCommands := []string{fmt.Sprintf("sudo chmod --reference=%s %s", path, path+newSuffix)}
+	if _, backedUp := r.backedUp.Load(host); !backedUp {
+		installCommands = append(installCommands, fmt.Sprintf("sudo cp -p %s %s", path, path+previousSuffix))
+	}
+	installCommands = append(installCommands, fmt.Sprintf("sudo mv -f %s %s", path+newSuffix, path))
+	if err := r.runRemote(ctx, host, strings.Join(installCommands, " && "), nil, nil); err != nil {
+		return false, fmt.Errorf("failed to replace %s on %s: %w", path, host, err)
+	}
+	r.backedUp.Store(host, true)
+
+	return true, nil
+}
+
+func (r *ConfigSSHRestarter) restart(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	if err := r.restarter.RestartNode(ctx, node); err != nil {
+		return err
+	}
+	return waitUntil(ctx, r.Opts.ReadyTimeout, r.Opts.ReadyPollInterval, func() error {
+		return r.Opts.Ready(node)
+	})
+}
+
+func (r *ConfigSSHRestarter) rollback(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	path := r.Opts.Path
+	rollbackCommand := strings.Join([]string{
+		fmt.Sprintf("sudo cp -p %s %s", path+previousSuffix, path+newSuffix),
+		fmt.Sprintf("sudo mv -f %s %s", path+newSuffix, path),
+	}, " && ")
+	unlock := r.hosts.lock(node.Host)
+	err := r.runRemote(ctx, node.Host, rollbackCommand, nil, nil)
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	return r.restarter.RestartNode(ctx, node)
+}
+
+// diffReporter logs a config diff, but only once for a run of nodes
+// with identical changes.
+type diffReporter struct {
+	mu       sync.Mutex
+	lastDiff string
+	lastHost string
+}
+
+func (d *diffReporter) report(logger *zap.SugaredLogger, host, diff string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if diff == d.lastDiff {
+		logger.Infof("Config changes on %s are the same as on %s", host, d.lastHost)
+		return
+	}
+
+	logger.Infof("Config changes on %s:\n%s", host, diff)
+	d.lastDiff = diff
+	d.lastHost = host
+}
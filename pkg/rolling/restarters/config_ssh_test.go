@@ -0,0 +1,135 @@
+package restarters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/tests/mock"
+)
+
+var _ = Describe("Test config ssh restarter", func() {
+	var (
+		node           *Ydb_Maintenance.Node
+		restarted      []uint32
+		remoteCommands []string
+		remoteCtxErrs  []error
+		remoteConfig   string
+		readyErr       error
+		restarter      *ConfigSSHRestarter
+	)
+
+	BeforeEach(func() {
+		node = mock.CreateNodesFromShortConfig([][]uint32{{1}}, nil)[0]
+		restarted = nil
+		remoteCommands = nil
+		remoteCtxErrs = nil
+		remoteConfig = "log_level: 5\n"
+		readyErr = nil
+
+		restarter = NewConfigSSHRestarter(
+			zap.S(),
+			[]string{},
+			recordingRestarter{NewStorageSSHRestarter(zap.S(), []string{}, ""), &restarted},
+			&ConfigSSHOpts{
+				ConfigOpts: ConfigOpts{
+					Content: []byte("log_level: 7\n"),
+					Ready: func(*Ydb_Maintenance.Node) error {
+						return readyErr
+					},
+				},
+			},
+		)
+		restarter.runRemote = func(ctx context.Context, _, command string, _ io.Reader, stdout io.Writer) error {
+			remoteCommands = append(remoteCommands, command)
+			remoteCtxErrs = append(remoteCtxErrs, ctx.Err())
+			if stdout != nil {
+				_, _ = stdout.Write([]byte(remoteConfig))
+			}
+			return nil
+		}
+	})
+
+	It("backs up and replaces the config before restarting the node", func() {
+		Expect(restarter.RestartNode(context.Background(), node)).To(Succeed())
+
+		Expect(remoteCommands).To(Equal([]string{
+			"sudo cat /opt/ydb/cfg/config.yaml",
+			"sudo tee /opt/ydb/cfg/config.yaml.new > /dev/null",
+			"sudo chmod --reference=/opt/ydb/cfg/config.yaml /opt/ydb/cfg/config.yaml.new && " +
+				"sudo cp -p /opt/ydb/cfg/config.yaml /opt/ydb/cfg/config.yaml.previous && " +
+				"sudo mv -f /opt/ydb/cfg/config.yaml.new /opt/ydb/cfg/config.yaml",
+		}))
+		Expect(restarted).To(Equal([]uint32{1}))
+	})
+
+	It("keeps the config backed up earlier in this run", func() {
+		Expect(restarter.RestartNode(context.Background(), node)).To(Succeed())
+		Expect(restarter.RestartNode(context.Background(), node)).To(Succeed())
+
+		Expect(remoteCommands).To(HaveLen(6))
+		Expect(remoteCommands[2]).To(ContainSubstring("config.yaml.previous"))
+		Expect(remoteCommands[5]).To(Equal(
+			"sudo chmod --reference=/opt/ydb/cfg/config.yaml /opt/ydb/cfg/config.yaml.new && " +
+				"sudo mv -f /opt/ydb/cfg/config.yaml.new /opt/ydb/cfg/config.yaml",
+		))
+	})
+
+	It("only restarts the node if the config is up to date", func() {
+		remoteConfig = "log_level: 7\n"
+
+		Expect(restarter.RestartNode(context.Background(), node)).To(Succeed())
+
+		Expect(remoteCommands).To(HaveLen(1))
+		Expect(restarted).To(Equal([]uint32{1}))
+	})
+
+	It("restores the previous config if the node does not come back", func() {
+		readyErr = errors.New("node 1 is in state ITEM_STATE_DOWN")
+
+		err := restarter.RestartNode(context.Background(), node)
+		Expect(err).To(MatchError(readyErr))
+		Expect(err).To(MatchError(ErrNotRetryable))
+
+		Expect(remoteCommands).To(HaveLen(4))
+		Expect(remoteCommands[3]).To(Equal(
+			"sudo cp -p /opt/ydb/cfg/config.yaml.previous /opt/ydb/cfg/config.yaml.new && " +
+				"sudo mv -f /opt/ydb/cfg/config.yaml.new /opt/ydb/cfg/config.yaml",
+		))
+		Expect(restarted).To(Equal([]uint32{1, 1}))
+	})
+
+	It("restores the previous config when the restart times out waiting for the node", func() {
+		readyErr = errors.New("node 1 is in state ITEM_STATE_DOWN")
+		restarter.Opts.ReadyTimeout = time.Minute
+		restarter.Opts.ReadyPollInterval = 10 * time.Millisecond
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Expect(restarter.RestartNode(ctx, node)).To(MatchError(context.DeadlineExceeded))
+
+		Expect(remoteCommands).To(HaveLen(4))
+		Expect(remoteCommands[3]).To(HavePrefix("sudo cp -p /opt/ydb/cfg/config.yaml.previous"))
+		Expect(remoteCtxErrs[3]).ToNot(HaveOccurred())
+		Expect(restarted).To(Equal([]uint32{1, 1}))
+	})
+	It("aborts the rollout after the first node that does not come back", func() {
+		readyErr = errors.New("node 1 is in state ITEM_STATE_DOWN")
+		Expect(restarter.RestartNode(context.Background(), node)).ToNot(Succeed())
+
+		readyErr = nil
+		other := mock.CreateNodesFromShortConfig([][]uint32{{2}}, nil)[0]
+		err := restarter.RestartNode(context.Background(), other)
+		Expect(err).To(MatchError(ErrNotRetryable))
+		Expect(err).To(MatchError(ContainSubstring("the rollout of /opt/ydb/cfg/config.yaml is aborted")))
+
+		Expect(remoteCommands).To(HaveLen(4))
+		Expect(restarted).To(Equal([]uint32{1, 1}))
+	})
+})
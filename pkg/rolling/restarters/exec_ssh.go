@@ -0,0 +1,58 @@
+package restarters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+
+	"go.uber.org/zap"
+)
+
+// sshFailedExitCode is what ssh exits with on its own errors, e.g. when the host is unreachable
+const sshFailedExitCode = 255
+
+// ErrSSHFailed is returned when ssh has failed and the script may not have run.
+var ErrSSHFailed = errors.New("ssh failed")
+
+// SSHExecutor runs shell scripts on hosts over the same ssh transport the ssh
+// restarters use. It does not restart anything and knows nothing about CMS.
+type SSHExecutor struct {
+	sshRestarter
+
+	sshArgs   []string
+	runRemote func(ctx context.Context, host, command string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+func NewSSHExecutor(logger *zap.SugaredLogger, sshArgs []string) *SSHExecutor {
+	e := &SSHExecutor{
+		sshRestarter: newSSHRestarter(logger),
+		sshArgs:      sshArgs,
+	}
+	e.runRemote = func(ctx context.Context, host, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+		return e.runRemoteCommand(ctx, host, command, e.sshArgs, stdin, stdout, stderr)
+	}
+	return e
+}
+
+// Run feeds the script into bash on the host and returns its exit code.
+// The script is passed over stdin, so it needs no quoting. An error means
+// the script could not be run at all, e.g. ssh could not be started or
+// could not connect, or that it was killed once ctx was done. Exit code
+// 255 is taken for an ssh failure, scripts should not exit with it.
+func (e *SSHExecutor) Run(ctx context.Context, host string, script []byte, stdout, stderr io.Writer) (int, error) {
+	err := e.runRemote(ctx, host, "bash -s", bytes.NewReader(script), stdout, stderr)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() == sshFailedExitCode {
+			return -1, ErrSSHFailed
+		}
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
@@ -0,0 +1,94 @@
+package restarters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+)
+
+var _ = Describe("Test ssh executor", func() {
+	var (
+		executor *SSHExecutor
+		hosts    []string
+		stdout   bytes.Buffer
+		stderr   bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		hosts = nil
+		stdout.Reset()
+		stderr.Reset()
+
+		executor = NewSSHExecutor(zap.S(), []string{})
+		// run the remote side locally instead of over ssh
+		executor.runRemote = func(_ context.Context, host, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+			hosts = append(hosts, host)
+			cmd := exec.Command("bash", "-c", command)
+			cmd.Stdin = stdin
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+			return cmd.Run()
+		}
+	})
+
+	It("passes the script over stdin and captures its streams", func() {
+		exitCode, err := executor.Run(context.Background(), "ydb-1.ydb.tech", []byte("echo \"$((1 + 2))\"\necho oops >&2\n"), &stdout, &stderr)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exitCode).To(Equal(0))
+		Expect(stdout.String()).To(Equal("3\n"))
+		Expect(stderr.String()).To(Equal("oops\n"))
+		Expect(hosts).To(Equal([]string{"ydb-1.ydb.tech"}))
+	})
+
+	It("reports the exit code of a failed script", func() {
+		exitCode, err := executor.Run(context.Background(), "ydb-1.ydb.tech", []byte("exit 3\n"), &stdout, &stderr)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exitCode).To(Equal(3))
+	})
+
+	It("reports exit code 255 as an ssh failure", func() {
+		exitCode, err := executor.Run(context.Background(), "ydb-1.ydb.tech", []byte("exit 255\n"), &stdout, &stderr)
+
+		Expect(err).To(MatchError(ErrSSHFailed))
+		Expect(exitCode).To(Equal(-1))
+	})
+
+	It("kills ssh once the context is done", func() {
+		dir := GinkgoT().TempDir()
+		pidFile := filepath.Join(dir, "ssh.pid")
+		// a hanging ssh that remembers its pid
+		fakeSSH := fmt.Sprintf("#!/bin/bash\necho $$ > %s\nexec sleep 30\n", pidFile)
+		Expect(os.WriteFile(filepath.Join(dir, "ssh"), []byte(fakeSSH), 0o755)).To(Succeed())
+		GinkgoT().Setenv("PATH", dir+":"+os.Getenv("PATH"))
+
+		ctx, cancel := context.WithTimeoutCause(context.Background(), 200*time.Millisecond, errors.New("too slow"))
+		defer cancel()
+
+		executor = NewSSHExecutor(zap.S(), []string{})
+		_, err := executor.Run(ctx, "ydb-1.ydb.tech", []byte("true\n"), &stdout, &stderr)
+		Expect(err).To(MatchError("remote command was killed: too slow"))
+
+		content, err := os.ReadFile(pidFile)
+		Expect(err).NotTo(HaveOccurred())
+		pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() error {
+			return syscall.Kill(pid, 0)
+		}).WithTimeout(time.Second).Should(MatchError(syscall.ESRCH))
+	})
+})
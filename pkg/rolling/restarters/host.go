@@ -0,0 +1,67 @@
+package restarters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+)
+
+// HostRestarter combines a storage and a tenant restarter, so that a single
+// rolling restart can lock a host and restart every ydbd process running on it.
+type HostRestarter struct {
+	storage Restarter
+	tenant  Restarter
+
+	mu           sync.RWMutex
+	storageNodes map[uint32]bool
+}
+
+// NewHostRestarter creates a HostRestarter. Either restarter can be nil,
+// then nodes of that kind are not selected.
+func NewHostRestarter(storage, tenant Restarter) *HostRestarter {
+	return &HostRestarter{
+		storage:      storage,
+		tenant:       tenant,
+		storageNodes: make(map[uint32]bool),
+	}
+}
+
+func (r *HostRestarter) Filter(spec FilterNodeParams, cluster ClusterNodesInfo) []*Ydb_Maintenance.Node {
+	var storageNodes, tenantNodes []*Ydb_Maintenance.Node
+	if r.storage != nil {
+		storageNodes = r.storage.Filter(spec, cluster)
+	}
+	if r.tenant != nil {
+		tenantNodes = r.tenant.Filter(spec, cluster)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, node := range storageNodes {
+		r.storageNodes[node.GetNodeId()] = true
+	}
+
+	return MergeAndUnique(storageNodes, tenantNodes)
+}
+
+func (r *HostRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	return r.RestartNodeAttempt(ctx, node, RestartAttempt{})
+}
+
+func (r *HostRestarter) RestartNodeAttempt(ctx context.Context, node *Ydb_Maintenance.Node, attempt RestartAttempt) error {
+	r.mu.RLock()
+	isStorage := r.storageNodes[node.GetNodeId()]
+	r.mu.RUnlock()
+
+	switch {
+	case isStorage:
+		return RestartNodeAttempt(ctx, r.storage, node, attempt)
+	case r.tenant != nil:
+		return RestartNodeAttempt(ctx, r.tenant, node, attempt)
+	default:
+		return fmt.Errorf("node %d was not selected for restart", node.GetNodeId())
+	}
+}
@@ -0,0 +1,83 @@
+package restarters
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/tests/mock"
+)
+
+type recordingRestarter struct {
+	Restarter
+	restarted *[]uint32
+}
+
+func (r recordingRestarter) RestartNode(_ context.Context, node *Ydb_Maintenance.Node) error {
+	*r.restarted = append(*r.restarted, node.GetNodeId())
+	return nil
+}
+
+var _ = Describe("Test host restarter", func() {
+	nodeGroups := [][]uint32{
+		{1, 2, 3},
+		{4, 5},
+	}
+	nodeInfoMap := map[uint32]mock.TestNodeInfo{
+		4: {
+			IsDynnode:  true,
+			TenantName: "fakeTenant",
+			Host:       "ydb-1.ydb.tech",
+		},
+		5: {
+			IsDynnode:  true,
+			TenantName: "fakeTenant",
+			Host:       "ydb-2.ydb.tech",
+		},
+	}
+	clusterInfo := ClusterNodesInfo{
+		AllNodes: mock.CreateNodesFromShortConfig(nodeGroups, nodeInfoMap),
+		TenantToNodeIds: map[string][]uint32{
+			"fakeTenant": {4, 5},
+		},
+	}
+
+	It("selects storage and tenant nodes of a host and restarts each with its own restarter", func() {
+		var storageRestarted, tenantRestarted []uint32
+		restarter := NewHostRestarter(
+			recordingRestarter{NewStorageSSHRestarter(zap.S(), []string{}, ""), &storageRestarted},
+			recordingRestarter{NewTenantSSHRestarter(zap.S(), []string{}, ""), &tenantRestarted},
+		)
+
+		filteredNodes := restarter.Filter(FilterNodeParams{
+			MaxStaticNodeID: DefaultMaxStaticNodeID,
+			SelectedHosts:   []string{"ydb-1.ydb.tech"},
+		}, clusterInfo)
+
+		filteredNodeIds := []uint32{}
+		for _, node := range filteredNodes {
+			filteredNodeIds = append(filteredNodeIds, node.NodeId)
+			Expect(restarter.RestartNode(context.Background(), node)).To(Succeed())
+		}
+
+		Expect(filteredNodeIds).To(Equal([]uint32{1, 4}))
+		Expect(storageRestarted).To(Equal([]uint32{1}))
+		Expect(tenantRestarted).To(Equal([]uint32{4}))
+	})
+
+	It("skips the kind of nodes without a restarter", func() {
+		restarter := NewHostRestarter(nil, NewTenantSSHRestarter(zap.S(), []string{}, ""))
+
+		filteredNodes := restarter.Filter(FilterNodeParams{
+			MaxStaticNodeID: DefaultMaxStaticNodeID,
+		}, clusterInfo)
+
+		Expect(filteredNodes).To(HaveLen(2))
+		for _, node := range filteredNodes {
+			Expect(node.GetDynamic()).NotTo(BeNil())
+		}
+	})
+})
@@ -1,6 +1,9 @@
 package restarters
 
 import (
+	"context"
+	"errors"
+
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 
 	"github.com/ydb-platform/ydbops/pkg/options"
@@ -11,7 +14,36 @@ type Restarter interface {
 		spec FilterNodeParams,
 		cluster ClusterNodesInfo,
 	) []*Ydb_Maintenance.Node
-	RestartNode(node *Ydb_Maintenance.Node) error
+	// RestartNode must give up and clean up after itself, e.g. kill the
+	// processes it has started, once ctx is done.
+	RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error
+}
+
+// ErrNotRetryable is wrapped by restart errors that another attempt will not
+// fix. Rolling restart gives up on such a node right away.
+var ErrNotRetryable = errors.New("not retryable")
+
+// RestartAttempt tells which maintenance task a restart belongs to and how
+// many times the node has been tried before.
+type RestartAttempt struct {
+	TaskUID string
+	// Number starts from 1
+	Number int
+}
+
+// AttemptRestarter is implemented by restarters that pass the details of
+// the attempt on, e.g. to a payload.
+type AttemptRestarter interface {
+	RestartNodeAttempt(ctx context.Context, node *Ydb_Maintenance.Node, attempt RestartAttempt) error
+}
+
+// RestartNodeAttempt restarts the node with the attempt details if the
+// restarter takes them, and with a plain RestartNode otherwise.
+func RestartNodeAttempt(ctx context.Context, r Restarter, node *Ydb_Maintenance.Node, attempt RestartAttempt) error {
+	if ar, ok := r.(AttemptRestarter); ok {
+		return ar.RestartNodeAttempt(ctx, node, attempt)
+	}
+	return r.RestartNode(ctx, node)
 }
 
 type ClusterNodesInfo struct {
@@ -1,10 +1,12 @@
 package restarters
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"strconv"
+	"time"
 
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 	"go.uber.org/zap"
@@ -214,3 +216,29 @@ func MergeAndUnique(nodeSlices ...[]*Ydb_Maintenance.Node) []*Ydb_Maintenance.No
 
 	return result
 }
+
+// waitUntil calls check every interval until it succeeds. When the timeout
+// expires or ctx is done, the last error of check is returned.
+func waitUntil(ctx context.Context, timeout, interval time.Duration, check func() error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, context.Cause(ctx))
+		case <-time.After(interval):
+		}
+	}
+}
+
+// RollbackTimeout bounds a rollback after a failed restart.
+var RollbackTimeout = 5 * time.Minute
+
+// rollbackContext is not done when ctx is: a rollback has to run even after the
+// restart was given up on, e.g. by --node-restart-timeout.
+func rollbackContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), RollbackTimeout)
+}
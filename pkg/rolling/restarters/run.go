@@ -1,9 +1,23 @@
 package restarters
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 	"go.uber.org/zap"
@@ -11,6 +25,31 @@ import (
 
 const (
 	HostnameEnvVar = "HOSTNAME"
+	NodeIDEnvVar   = "YDB_NODE_ID"
+	NodePortEnvVar = "YDB_NODE_PORT"
+	TenantEnvVar   = "YDB_TENANT"
+	DCEnvVar       = "YDB_DC"
+	RackEnvVar     = "YDB_RACK"
+	VersionEnvVar  = "YDB_VERSION"
+	TaskUIDEnvVar  = "YDB_TASK_UID"
+	AttemptEnvVar  = "YDB_ATTEMPT"
+)
+
+const (
+	// PayloadStatusOK is the status of a successful payload report
+	PayloadStatusOK = "ok"
+
+	// payloadReportFD is the file descriptor a payload may write its report to
+	payloadReportFD = 3
+
+	// how long a remote payload may take to exit after SIGTERM
+	remoteKillAfter = 10 * time.Second
+	// how long killing a remote payload may take once the restart is given up
+	remoteKillTimeout = 30 * time.Second
+
+	// what the remote side prints after the report marker
+	remoteStateTimedOut = "timedout"
+	remoteStateFinished = "finished"
 )
 
 type RunRestarter struct {
@@ -18,39 +57,365 @@ type RunRestarter struct {
 	logger      *zap.SugaredLogger
 	storageOnly bool
 	dynnodeOnly bool
+
+	executor *SSHExecutor
+
+	mu      sync.Mutex
+	results []PayloadResult
+}
+
+// PayloadNode is the description of the node a payload receives as JSON on stdin.
+type PayloadNode struct {
+	NodeID     uint32 `json:"nodeId"`
+	Host       string `json:"host"`
+	Port       uint32 `json:"port"`
+	Tenant     string `json:"tenant,omitempty"`
+	DataCenter string `json:"dataCenter,omitempty"`
+	Rack       string `json:"rack,omitempty"`
+	Version    string `json:"version,omitempty"`
+	TaskUID    string `json:"taskUid,omitempty"`
+	Attempt    int    `json:"attempt,omitempty"`
+}
+
+// PayloadReport is what a payload may write to fd 3 as a single JSON line.
+// A payload that reports a status other than "ok" fails even with a zero
+// exit code. Failures are retried unless retryable is false.
+type PayloadReport struct {
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+	Retryable *bool  `json:"retryable,omitempty"`
+}
+
+// PayloadResult is the outcome of a single payload invocation.
+type PayloadResult struct {
+	NodeID   uint32
+	Host     string
+	Attempt  int
+	ExitCode int
+	TimedOut bool
+	Report   *PayloadReport
+	Stdout   string
+	Stderr   string
+}
+
+// PayloadError is a failed payload invocation. It wraps ErrNotRetryable if
+// the payload said another attempt would not help.
+type PayloadError struct {
+	ExitCode  int
+	TimedOut  bool
+	Status    string
+	Message   string
+	Retryable bool
+}
+
+func (e *PayloadError) Error() string {
+	var msg string
+	switch {
+	case e.TimedOut:
+		msg = "payload timed out"
+	case e.Status != "" && e.Status != PayloadStatusOK:
+		msg = fmt.Sprintf("payload reported status %s", e.Status)
+	default:
+		msg = fmt.Sprintf("payload finished with exit code %d", e.ExitCode)
+	}
+
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if !e.Retryable {
+		msg += " (not retryable)"
+	}
+	return msg
+}
+
+func (e *PayloadError) Unwrap() error {
+	if !e.Retryable {
+		return ErrNotRetryable
+	}
+	return nil
 }
 
-func (r *RunRestarter) RestartNode(node *Ydb_Maintenance.Node) error {
+func (r PayloadResult) err() error {
+	failed := r.TimedOut || r.ExitCode != 0
+	if r.Report != nil && r.Report.Status != PayloadStatusOK {
+		failed = true
+	}
+	if !failed {
+		return nil
+	}
+
+	payloadErr := &PayloadError{
+		ExitCode:  r.ExitCode,
+		TimedOut:  r.TimedOut,
+		Retryable: true,
+	}
+	if r.Report != nil {
+		payloadErr.Status = r.Report.Status
+		payloadErr.Message = r.Report.Message
+		if r.Report.Retryable != nil {
+			payloadErr.Retryable = *r.Report.Retryable
+		}
+	}
+	return payloadErr
+}
+
+func (r *RunRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	return r.RestartNodeAttempt(ctx, node, RestartAttempt{})
+}
+
+func (r *RunRestarter) RestartNodeAttempt(ctx context.Context, node *Ydb_Maintenance.Node, attempt RestartAttempt) error {
+	env := NodeEnv(node, attempt)
+	input, err := json.Marshal(payloadNode(node, attempt))
+	if err != nil {
+		return fmt.Errorf("failed to serialize node description for the payload: %w", err)
+	}
+
+	var result PayloadResult
+	if r.Opts.Remote {
+		result, err = r.runRemote(ctx, node, env, input)
+	} else {
+		result, err = r.runLocal(ctx, env, input)
+	}
+	if err != nil {
+		return err
+	}
+
+	result.NodeID = node.GetNodeId()
+	result.Host = node.GetHost()
+	result.Attempt = attempt.Number
+	r.mu.Lock()
+	r.results = append(r.results, result)
+	r.mu.Unlock()
+
+	return result.err()
+}
+
+// runLocal runs the payload in its own process group, so that everything
+// it has started is killed on timeout or once ctx is done. The output of
+// what the payload leaves running after it exits is not waited for long.
+func (r *RunRestarter) runLocal(ctx context.Context, env []string, input []byte) (PayloadResult, error) {
+	payloadCtx := ctx
+	if r.Opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		payloadCtx, cancel = context.WithTimeout(ctx, r.Opts.Timeout)
+		defer cancel()
+	}
+
 	//nolint:gosec
 	cmd := exec.Command(r.Opts.PayloadFilePath)
 
-	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", HostnameEnvVar, node.Host))
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
-	stdout, _ := cmd.StdoutPipe()
-	stderr, _ := cmd.StderrPipe()
+	// stdout, stderr and the report, the payload gets the write ends
+	readers, writers := make([]*os.File, 0, 3), make([]*os.File, 0, 3)
+	defer func() {
+		for _, f := range readers {
+			f.Close()
+		}
+	}()
+	for range 3 {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			for _, f := range writers {
+				f.Close()
+			}
+			return PayloadResult{}, fmt.Errorf("failed to create a pipe for the payload: %w", err)
+		}
+		readers = append(readers, reader)
+		writers = append(writers, writer)
+	}
+	cmd.Stdout = writers[0]
+	cmd.Stderr = writers[1]
+	// ExtraFiles start from fd 3
+	cmd.ExtraFiles = []*os.File{writers[2]}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("error running payload file: %w", err)
+	err := cmd.Start()
+	for _, f := range writers {
+		f.Close()
 	}
+	if err != nil {
+		return PayloadResult{}, fmt.Errorf("error running payload file: %w", err)
+	}
+
+	var killed atomic.Bool
+	stopKill := context.AfterFunc(payloadCtx, func() {
+		killed.Store(true)
+		if ctx.Err() == nil {
+			r.logger.Warnf("Payload did not finish in %s, killing it", r.Opts.Timeout)
+		}
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	})
+	defer stopKill()
 
-	go StreamPipeIntoLogger(stdout, r.logger)
-	go StreamPipeIntoLogger(stderr, r.logger)
+	var (
+		wg                   sync.WaitGroup
+		stdoutBuf, stderrBuf bytes.Buffer
+		report               []byte
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		StreamPipeIntoLogger(io.NopCloser(io.TeeReader(readers[0], &stdoutBuf)), r.logger)
+	}()
+	go func() {
+		defer wg.Done()
+		StreamPipeIntoLogger(io.NopCloser(io.TeeReader(readers[1], &stderrBuf)), r.logger)
+	}()
+	go func() {
+		defer wg.Done()
+		report, _ = io.ReadAll(readers[2])
+	}()
+	readDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(readDone)
+	}()
 
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("payload command finished with an error: %w", err)
+	err = cmd.Wait()
+	// processes the payload has left in the background may keep the pipes open
+	select {
+	case <-readDone:
+	case <-time.After(killedOutputWaitDelay):
+		r.logger.Warnf("Payload has exited, but its output is still open, stopped reading it after %s",
+			killedOutputWaitDelay)
+		for _, f := range readers {
+			_ = f.SetReadDeadline(time.Now())
+		}
+		<-readDone
+	}
+
+	if killed.Load() && ctx.Err() != nil {
+		return PayloadResult{}, fmt.Errorf("payload was killed: %w", context.Cause(ctx))
+	}
+	result := PayloadResult{
+		ExitCode: cmd.ProcessState.ExitCode(),
+		TimedOut: killed.Load(),
+		Report:   r.parseReport(report),
+		Stdout:   stdoutBuf.String(),
+		Stderr:   stderrBuf.String(),
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return result, fmt.Errorf("payload command finished with an error: %w", err)
+	}
+	return result, nil
+}
+
+// runRemote copies the payload to the node inside a wrapper script and runs it
+// there, see remotePayloadScript.
+func (r *RunRestarter) runRemote(ctx context.Context, node *Ydb_Maintenance.Node, env []string, input []byte) (PayloadResult, error) {
+	payload, err := os.ReadFile(r.Opts.PayloadFilePath)
+	if err != nil {
+		return PayloadResult{}, fmt.Errorf("failed to read payload file: %w", err)
+	}
+
+	id := fmt.Sprintf("%016x", rand.Uint64())
+	opts := remotePayloadOpts{
+		sudo:         r.Opts.Sudo,
+		timeout:      r.Opts.Timeout,
+		dir:          "/tmp/ydbops-payload." + id,
+		reportMarker: "YDBOPS_REPORT_" + id,
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := r.executor.Run(ctx, node.GetHost(), remotePayloadScript(payload, env, input, opts), &stdout, &stderr)
+	if err != nil {
+		if ctx.Err() != nil {
+			r.killRemote(ctx, node.GetHost(), opts)
+		}
+		return PayloadResult{}, fmt.Errorf("failed to run payload on %s: %w", node.GetHost(), err)
+	}
+
+	output, trailer, _ := strings.Cut(stdout.String(), "\n"+opts.reportMarker+"\n")
+	state, report, _ := strings.Cut(trailer, "\n")
+
+	for _, line := range strings.Split(strings.TrimRight(output+stderr.String(), "\n"), "\n") {
+		if line != "" {
+			r.logger.Infof("[%s] %s", node.GetHost(), line)
+		}
+	}
+
+	return PayloadResult{
+		ExitCode: exitCode,
+		TimedOut: state == remoteStateTimedOut,
+		Report:   r.parseReport([]byte(report)),
+		Stdout:   output,
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+// killRemote kills the payload on the node once its ssh session has been
+// killed: the payload would keep running there otherwise.
+func (r *RunRestarter) killRemote(ctx context.Context, host string, opts remotePayloadOpts) {
+	killCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), remoteKillTimeout)
+	defer cancel()
+
+	r.logger.Infof("Killing the payload on %s", host)
+	var stderr bytes.Buffer
+	exitCode, err := r.executor.Run(killCtx, host, remoteKillScript(opts), io.Discard, &stderr)
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("exit code %d: %s", exitCode, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		r.logger.Warnf("Failed to kill the payload on %s, it may still be running: %v", host, err)
 	}
-	return nil
+}
+
+// parseReport takes the last line the payload has written to fd 3.
+func (r *RunRestarter) parseReport(content []byte) *PayloadReport {
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == "" {
+		return nil
+	}
+
+	report := &PayloadReport{}
+	if err := json.Unmarshal([]byte(last), report); err != nil {
+		r.logger.Warnf("Ignoring malformed payload report %q: %v", last, err)
+		return nil
+	}
+	return report
+}
+
+// Results returns the outcome of every payload invocation so far,
+// ordered by node id and attempt.
+func (r *RunRestarter) Results() []PayloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := append([]PayloadResult{}, r.results...)
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].NodeID != results[j].NodeID {
+			return results[i].NodeID < results[j].NodeID
+		}
+		return results[i].Attempt < results[j].Attempt
+	})
+	return results
 }
 
 type RunRestarterParams struct {
 	PayloadFilePath string
+
+	// Timeout kills the payload and everything it has started, zero means no timeout
+	Timeout time.Duration
+
+	// Remote runs the payload on the node itself instead of the local machine
+	Remote  bool
+	Sudo    bool
+	SSHArgs []string
 }
 
 func NewRunRestarter(logger *zap.SugaredLogger, params *RunRestarterParams) *RunRestarter {
-	return &RunRestarter{
+	r := &RunRestarter{
 		Opts:   params,
 		logger: logger,
 	}
+	if params.Remote {
+		r.executor = NewSSHExecutor(logger, params.SSHArgs)
+	}
+	return r
 }
 
 func (r *RunRestarter) SetStorageOnly() {
@@ -81,3 +446,141 @@ func (r *RunRestarter) Filter(spec FilterNodeParams, cluster ClusterNodesInfo) [
 
 	return filteredNodes
 }
+
+func payloadNode(node *Ydb_Maintenance.Node, attempt RestartAttempt) PayloadNode {
+	return PayloadNode{
+		NodeID:     node.GetNodeId(),
+		Host:       node.GetHost(),
+		Port:       node.GetPort(),
+		Tenant:     node.GetDynamic().GetTenant(),
+		DataCenter: node.GetLocation().GetDataCenter(),
+		Rack:       node.GetLocation().GetRack(),
+		Version:    node.GetVersion(),
+		TaskUID:    attempt.TaskUID,
+		Attempt:    attempt.Number,
+	}
+}
+
+// NodeEnv describes the node to payloads and hooks, as KEY=VALUE pairs.
+func NodeEnv(node *Ydb_Maintenance.Node, attempt RestartAttempt) []string {
+	attemptNumber := ""
+	if attempt.Number > 0 {
+		attemptNumber = strconv.Itoa(attempt.Number)
+	}
+
+	return []string{
+		fmt.Sprintf("%s=%s", HostnameEnvVar, node.GetHost()),
+		fmt.Sprintf("%s=%d", NodeIDEnvVar, node.GetNodeId()),
+		fmt.Sprintf("%s=%d", NodePortEnvVar, node.GetPort()),
+		fmt.Sprintf("%s=%s", TenantEnvVar, node.GetDynamic().GetTenant()),
+		fmt.Sprintf("%s=%s", DCEnvVar, node.GetLocation().GetDataCenter()),
+		fmt.Sprintf("%s=%s", RackEnvVar, node.GetLocation().GetRack()),
+		fmt.Sprintf("%s=%s", VersionEnvVar, node.GetVersion()),
+		fmt.Sprintf("%s=%s", TaskUIDEnvVar, attempt.TaskUID),
+		fmt.Sprintf("%s=%s", AttemptEnvVar, attemptNumber),
+	}
+}
+
+type remotePayloadOpts struct {
+	sudo    bool
+	timeout time.Duration
+	// dir is where the payload is unpacked on the node, it is chosen
+	// locally so that the payload can be found to be killed
+	dir string
+	// reportMarker separates the payload report from its stdout
+	reportMarker string
+}
+
+// remotePayloadSupervisor runs the payload in a session of its own and kills
+// the whole session once the payload timeout expires. The payload writes the
+// id of its process group to pgid, and does not start if the restart has
+// already been given up, see remoteKillScript. Arguments: the payload
+// directory, the timeout and the kill delay, in seconds.
+const remotePayloadSupervisor = `dir=$1 timeout=$2 kill_after=$3
+setsid bash -c 'echo $$ > "$0/pgid"; [ -e "$0/killed" ] && exit 1; exec "$0/payload" %d>"$0/report"' "$dir" <&0 &
+payload=$!
+if [ "$timeout" -gt 0 ]; then
+	(
+		deadline=$((SECONDS + timeout))
+		while kill -0 "$payload" 2>/dev/null; do
+			if [ "$SECONDS" -ge "$deadline" ]; then
+				touch "$dir/timedout"
+				kill -TERM -- -"$payload"
+				for _ in $(seq "$kill_after"); do
+					kill -0 -- -"$payload" 2>/dev/null || exit
+					sleep 1
+				done
+				kill -KILL -- -"$payload"
+				exit
+			fi
+			sleep 1
+		done
+	) </dev/null >/dev/null 2>&1 &
+fi
+wait "$payload"
+`
+
+// remotePayloadScript builds a bash script that unpacks the payload into a
+// directory on the node, runs it with the environment and the node
+// description on stdin, prints whether it has timed out and its report
+// after the marker and cleans up. The exit code of the script is the exit
+// code of the payload.
+func remotePayloadScript(payload []byte, env []string, input []byte, opts remotePayloadOpts) []byte {
+	command := []string{"env"}
+	for _, kv := range env {
+		command = append(command, shellQuote(kv))
+	}
+	timeoutSeconds := 0
+	if opts.timeout > 0 {
+		timeoutSeconds = max(1, int(opts.timeout.Seconds()))
+	}
+	// sudo closes inherited descriptors, so fd 3 is opened behind it
+	command = append(command,
+		"bash", "-c", shellQuote(fmt.Sprintf(remotePayloadSupervisor, payloadReportFD)), "ydbops-payload",
+		`"$dir"`, strconv.Itoa(timeoutSeconds), strconv.Itoa(int(remoteKillAfter.Seconds())))
+	if opts.sudo {
+		command = append([]string{"sudo"}, command...)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("dir=%s\n", shellQuote(opts.dir)))
+	sb.WriteString("mkdir -m 700 \"$dir\" || exit 1\n")
+	sb.WriteString("trap 'rm -rf \"$dir\"' EXIT\n")
+	sb.WriteString("base64 -d > \"$dir/payload\" <<'YDBOPS_PAYLOAD' || exit 1\n")
+	sb.WriteString(base64.StdEncoding.EncodeToString(payload))
+	sb.WriteString("\nYDBOPS_PAYLOAD\n")
+	sb.WriteString("chmod +x \"$dir/payload\" || exit 1\n")
+	sb.WriteString(strings.Join(command, " ") + " <<'YDBOPS_NODE'\n")
+	sb.WriteString(string(input))
+	sb.WriteString("\nYDBOPS_NODE\n")
+	sb.WriteString("status=$?\n")
+	sb.WriteString(fmt.Sprintf("printf '\\n%%s\\n' %s\n", opts.reportMarker))
+	sb.WriteString(fmt.Sprintf("if [ -e \"$dir/timedout\" ]; then echo %s; else echo %s; fi\n",
+		remoteStateTimedOut, remoteStateFinished))
+	sb.WriteString("cat \"$dir/report\" 2>/dev/null\n")
+	sb.WriteString("exit $status\n")
+	return []byte(sb.String())
+}
+
+// remoteKillScript builds a bash script that kills the payload started by
+// remotePayloadScript with the same options, together with everything it
+// has started. A payload that has not started yet never starts.
+func remoteKillScript(opts remotePayloadOpts) []byte {
+	kill := "kill"
+	if opts.sudo {
+		kill = "sudo kill"
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("dir=%s\n", shellQuote(opts.dir)))
+	sb.WriteString("mkdir -p \"$dir\" && touch \"$dir/killed\" || exit 1\n")
+	sb.WriteString("if [ -s \"$dir/pgid\" ]; then\n")
+	sb.WriteString(fmt.Sprintf("\t%s -KILL -- -\"$(cat \"$dir/pgid\")\" 2>/dev/null\n", kill))
+	sb.WriteString("\trm -rf \"$dir\"\n")
+	sb.WriteString("fi\n")
+	return []byte(sb.String())
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
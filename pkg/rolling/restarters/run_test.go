@@ -0,0 +1,200 @@
+package restarters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/tests/mock"
+)
+
+var _ = Describe("Test run restarter", func() {
+	var (
+		node      *Ydb_Maintenance.Node
+		payload   string
+		restarter *RunRestarter
+	)
+
+	writePayload := func(content string) {
+		Expect(os.WriteFile(payload, []byte(content), 0o755)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		node = mock.CreateNodesFromShortConfig([][]uint32{{1}, {2}}, map[uint32]mock.TestNodeInfo{
+			2: {
+				IsDynnode:  true,
+				TenantName: "/Root/db1",
+				Version:    "24.1.1",
+			},
+		})[1]
+		payload = filepath.Join(GinkgoT().TempDir(), "payload.sh")
+
+		restarter = NewRunRestarter(zap.S(), &RunRestarterParams{
+			PayloadFilePath: payload,
+			Remote:          true,
+		})
+		// run the remote side locally instead of over ssh
+		restarter.executor.runRemote = func(ctx context.Context, _, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+			cmd := exec.CommandContext(ctx, "bash", "-c", command)
+			cmd.Stdin = stdin
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+			// like a killed ssh session, which does not wait for the remote side
+			cmd.WaitDelay = 100 * time.Millisecond
+			err := cmd.Run()
+			if err != nil && ctx.Err() != nil {
+				return fmt.Errorf("remote command was killed: %w", context.Cause(ctx))
+			}
+			return err
+		}
+	})
+
+	It("runs the payload on the node with node metadata in the environment", func() {
+		writePayload("#!/bin/bash\necho \"$YDB_NODE_ID $YDB_TENANT $YDB_VERSION $YDB_TASK_UID $YDB_ATTEMPT\"\n")
+
+		attempt := RestartAttempt{TaskUID: "rolling-restart-1", Number: 2}
+		Expect(restarter.RestartNodeAttempt(context.Background(), node, attempt)).To(Succeed())
+
+		results := restarter.Results()
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].NodeID).To(Equal(uint32(2)))
+		Expect(results[0].Attempt).To(Equal(2))
+		Expect(results[0].ExitCode).To(Equal(0))
+		Expect(results[0].Stdout).To(Equal("2 /Root/db1 24.1.1 rolling-restart-1 2\n"))
+	})
+
+	It("fails the node with the exit code of the payload", func() {
+		writePayload("#!/bin/bash\necho 'it'\\''s broken' >&2\nexit 4\n")
+
+		err := restarter.RestartNode(context.Background(), node)
+		Expect(err).To(MatchError("payload finished with exit code 4"))
+		Expect(errors.Is(err, ErrNotRetryable)).To(BeFalse())
+
+		results := restarter.Results()
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].ExitCode).To(Equal(4))
+		Expect(results[0].Stderr).To(Equal("it's broken\n"))
+	})
+
+	It("takes the report of a remote payload from fd 3", func() {
+		writePayload("#!/bin/bash\necho working\necho '{\"status\": \"failed\", \"message\": \"disk busy\", \"retryable\": false}' >&3\n")
+
+		err := restarter.RestartNode(context.Background(), node)
+		Expect(err).To(MatchError("payload reported status failed: disk busy (not retryable)"))
+		Expect(errors.Is(err, ErrNotRetryable)).To(BeTrue())
+
+		results := restarter.Results()
+		Expect(results[0].Stdout).To(Equal("working\n"))
+	})
+
+	It("does not take a remote payload exiting with the exit code of timeout for a timeout", func() {
+		restarter.Opts.Timeout = 10 * time.Second
+		writePayload("#!/bin/bash\nexit 124\n")
+
+		Expect(restarter.RestartNode(context.Background(), node)).To(MatchError("payload finished with exit code 124"))
+		Expect(restarter.Results()[0].TimedOut).To(BeFalse())
+	})
+
+	Context("with processes left behind", func() {
+		var childPidFile string
+
+		// the payload starts a child that would outlive it
+		BeforeEach(func() {
+			childPidFile = filepath.Join(GinkgoT().TempDir(), "child.pid")
+			writePayload(fmt.Sprintf("#!/bin/bash\nsleep 30 &\necho $! > %s\nwait\n", childPidFile))
+		})
+
+		childIsGone := func() error {
+			content, err := os.ReadFile(childPidFile)
+			if err != nil {
+				return err
+			}
+			pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+			if err != nil {
+				return err
+			}
+			return syscall.Kill(pid, 0)
+		}
+
+		It("kills the remote payload process group on timeout", func() {
+			restarter.Opts.Timeout = time.Second
+
+			started := time.Now()
+			Expect(restarter.RestartNode(context.Background(), node)).To(MatchError("payload timed out"))
+			Expect(time.Since(started)).To(BeNumerically("<", 10*time.Second))
+			Expect(childIsGone()).To(MatchError(syscall.ESRCH))
+		})
+
+		It("kills the remote payload process group once the context is done", func() {
+			ctx, cancel := context.WithTimeoutCause(context.Background(), time.Second, errors.New("too slow"))
+			defer cancel()
+
+			started := time.Now()
+			Expect(restarter.RestartNode(ctx, node)).To(MatchError(ContainSubstring("too slow")))
+			Expect(time.Since(started)).To(BeNumerically("<", 10*time.Second))
+			Eventually(childIsGone).Should(MatchError(syscall.ESRCH))
+		})
+	})
+
+	Context("locally", func() {
+		BeforeEach(func() {
+			restarter = NewRunRestarter(zap.S(), &RunRestarterParams{
+				PayloadFilePath: payload,
+			})
+		})
+
+		It("passes the node description as JSON on stdin", func() {
+			writePayload("#!/bin/bash\ngrep -q '\"tenant\":\"/Root/db1\"' && echo '{\"status\": \"ok\"}' >&3\n")
+
+			Expect(restarter.RestartNode(context.Background(), node)).To(Succeed())
+			Expect(restarter.Results()[0].Report).To(Equal(&PayloadReport{Status: PayloadStatusOK}))
+		})
+
+		It("kills the payload process group on timeout", func() {
+			restarter.Opts.Timeout = 200 * time.Millisecond
+			// the child holds stdout open, the restarter would hang if it survived
+			writePayload("#!/bin/bash\nsleep 30 &\nwait\n")
+
+			started := time.Now()
+			err := restarter.RestartNode(context.Background(), node)
+			Expect(err).To(MatchError("payload timed out"))
+			Expect(time.Since(started)).To(BeNumerically("<", 10*time.Second))
+		})
+
+		It("does not wait for the output of processes the payload has left running", func() {
+			writePayload("#!/bin/bash\necho done\necho '{\"status\": \"ok\"}' >&3\nsleep 30 &\n")
+
+			started := time.Now()
+			Expect(restarter.RestartNode(context.Background(), node)).To(Succeed())
+			Expect(time.Since(started)).To(BeNumerically("<", 10*time.Second))
+			Expect(restarter.Results()[0].Stdout).To(Equal("done\n"))
+			Expect(restarter.Results()[0].Report).To(Equal(&PayloadReport{Status: PayloadStatusOK}))
+		})
+
+		It("kills the payload process group once the context is done", func() {
+			writePayload("#!/bin/bash\nsleep 30 &\nwait\n")
+
+			ctx, cancel := context.WithTimeoutCause(context.Background(), 200*time.Millisecond, errors.New("too slow"))
+			defer cancel()
+
+			started := time.Now()
+			err := restarter.RestartNode(ctx, node)
+			Expect(err).To(MatchError("payload was killed: too slow"))
+			Expect(time.Since(started)).To(BeNumerically("<", 10*time.Second))
+			Expect(restarter.Results()).To(BeEmpty())
+		})
+	})
+})
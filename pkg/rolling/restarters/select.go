@@ -0,0 +1,112 @@
+package restarters
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+
+	"github.com/ydb-platform/ydbops/pkg/options"
+	"github.com/ydb-platform/ydbops/pkg/utils"
+)
+
+// PrepareRestarters returns the storage and tenant restarters the targeting options ask for:
+// k8s restarters with --kubeconfig, ssh restarters otherwise.
+func PrepareRestarters(
+	opts *options.TargetingOptions,
+	sshArgs []string,
+	customSystemdUnitName string,
+	tenantSystemdUnitName string,
+	restartDuration int,
+) (storage, tenant Restarter) {
+	if opts.KubeconfigPath != "" {
+		storage = NewStorageK8sRestarter(
+			options.Logger,
+			&StorageK8sRestarterOptions{
+				K8sRestarterOptions: &K8sRestarterOptions{
+					KubeconfigPath:  opts.KubeconfigPath,
+					Namespace:       opts.K8sNamespace,
+					RestartDuration: time.Duration(restartDuration) * time.Second,
+				},
+			},
+		)
+		tenant = NewTenantK8sRestarter(
+			options.Logger,
+			&TenantK8sRestarterOptions{
+				K8sRestarterOptions: &K8sRestarterOptions{
+					KubeconfigPath:  opts.KubeconfigPath,
+					Namespace:       opts.K8sNamespace,
+					RestartDuration: time.Duration(restartDuration) * time.Second,
+				},
+			},
+		)
+		return storage, tenant
+	}
+
+	storage = NewStorageSSHRestarter(
+		options.Logger,
+		sshArgs,
+		customSystemdUnitName,
+	)
+	if tenantSystemdUnitName == "" {
+		tenantSystemdUnitName = customSystemdUnitName
+	}
+	tenant = NewTenantSSHRestarter(
+		options.Logger,
+		sshArgs,
+		tenantSystemdUnitName,
+	)
+	return storage, tenant
+}
+
+// SelectNodes returns the nodes matched by the targeting options, the same
+// way restart would select them.
+func SelectNodes(o *options.TargetingOptions, nodes []*Ydb_Maintenance.Node) ([]*Ydb_Maintenance.Node, error) {
+	nodeIds, errIds := utils.GetNodeIds(o.Hosts)
+	hostFQDNs, errFqdns := utils.GetNodeFQDNs(o.Hosts)
+	if errIds != nil && errFqdns != nil {
+		return nil, fmt.Errorf(
+			"failed to parse --hosts argument as node ids (%w) or host fqdns (%w)",
+			errIds,
+			errFqdns,
+		)
+	}
+
+	// Restarters are only used for their Filter component here, ssh arguments,
+	// systemd unit and restart duration do not matter.
+	storageRestarter, tenantRestarter := PrepareRestarters(
+		o,
+		[]string{},
+		"",
+		"",
+		0,
+	)
+
+	filterNodeParams := FilterNodeParams{
+		Version:             o.VersionSpec,
+		SelectedTenants:     o.TenantList,
+		SelectedNodeIds:     nodeIds,
+		SelectedHosts:       hostFQDNs,
+		SelectedDatacenters: o.Datacenters,
+		StartedTime:         o.StartedTime,
+		ExcludeHosts:        o.ExcludeHosts,
+		MaxStaticNodeID:     uint32(o.MaxStaticNodeID),
+	}
+
+	clusterNodesInfo := ClusterNodesInfo{
+		AllNodes:        nodes,
+		TenantToNodeIds: utils.PopulateTenantToNodesMapping(nodes),
+	}
+
+	bothUnspecified := !o.Storage && !o.Tenant
+
+	targetedNodes := make([]*Ydb_Maintenance.Node, 0, len(nodes))
+	if o.Storage || bothUnspecified {
+		targetedNodes = append(targetedNodes, storageRestarter.Filter(filterNodeParams, clusterNodesInfo)...)
+	}
+	if o.Tenant || bothUnspecified {
+		targetedNodes = append(targetedNodes, tenantRestarter.Filter(filterNodeParams, clusterNodesInfo)...)
+	}
+
+	return targetedNodes, nil
+}
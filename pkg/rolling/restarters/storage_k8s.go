@@ -1,6 +1,8 @@
 package restarters
 
 import (
+	"context"
+
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 	"go.uber.org/zap"
 )
@@ -29,8 +31,8 @@ func NewStorageK8sRestarter(logger *zap.SugaredLogger, params *StorageK8sRestart
 	}
 }
 
-func (r StorageK8sRestarter) RestartNode(node *Ydb_Maintenance.Node) error {
-	return r.restartNodeByRestartingPod(node.Host, node.Port, r.Opts.namespace)
+func (r StorageK8sRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	return r.restartNodeByRestartingPod(ctx, node.Host, node.Port, r.Opts.namespace)
 }
 
 func populateWithK8sRules(
@@ -1,6 +1,8 @@
 package restarters
 
 import (
+	"context"
+
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 	"go.uber.org/zap"
 )
@@ -11,7 +13,7 @@ type StorageSSHRestarter struct {
 	Opts *StorageSSHOpts
 }
 
-func (r StorageSSHRestarter) RestartNode(node *Ydb_Maintenance.Node) error {
+func (r StorageSSHRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
 	r.logger.Infof("Restarting storage node %s", node.Host)
 
 	systemdUnitName := defaultStorageSystemdUnit
@@ -19,7 +21,7 @@ func (r StorageSSHRestarter) RestartNode(node *Ydb_Maintenance.Node) error {
 		systemdUnitName = r.Opts.storageUnit
 	}
 
-	return r.restartNodeBySystemdUnit(node, systemdUnitName, r.Opts.sshArgs)
+	return r.restartNodeBySystemdUnit(ctx, node, systemdUnitName, r.Opts.sshArgs)
 }
 
 func NewStorageSSHRestarter(logger *zap.SugaredLogger, sshArgs []string, systemdUnit string) *StorageSSHRestarter {
@@ -1,6 +1,7 @@
 package restarters
 
 import (
+	"context"
 	"time"
 
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
@@ -37,8 +38,8 @@ func NewTenantK8sRestarter(logger *zap.SugaredLogger, params *TenantK8sRestarter
 	}
 }
 
-func (r TenantK8sRestarter) RestartNode(node *Ydb_Maintenance.Node) error {
-	return r.restartNodeByRestartingPod(node.Host, node.Port, r.Opts.namespace)
+func (r TenantK8sRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	return r.restartNodeByRestartingPod(ctx, node.Host, node.Port, r.Opts.namespace)
 }
 
 func applyTenantK8sFilteringRules(
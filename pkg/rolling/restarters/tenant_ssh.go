@@ -1,7 +1,9 @@
 package restarters
 
 import (
-	"fmt"
+	"context"
+	"path"
+	"strings"
 
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 	"go.uber.org/zap"
@@ -15,6 +17,9 @@ type TenantSSHRestarter struct {
 
 const (
 	defaultTenantSystemdUnit = "ydb-server-mt-starter"
+
+	// tenantUnitPlaceholder lets hosts with several tenant nodes use a unit per tenant
+	tenantUnitPlaceholder = "{tenant}"
 )
 
 func NewTenantSSHRestarter(logger *zap.SugaredLogger, sshArgs []string, systemdUnit string) *TenantSSHRestarter {
@@ -29,13 +34,18 @@ func NewTenantSSHRestarter(logger *zap.SugaredLogger, sshArgs []string, systemdU
 	}
 }
 
-func (r TenantSSHRestarter) RestartNode(node *Ydb_Maintenance.Node) error {
+func (r TenantSSHRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
 	systemdUnitName := defaultTenantSystemdUnit
 	if r.Opts.tenantUnit != "" {
 		systemdUnitName = r.Opts.tenantUnit
 	}
+	systemdUnitName = strings.ReplaceAll(
+		systemdUnitName,
+		tenantUnitPlaceholder,
+		path.Base(node.GetDynamic().GetTenant()),
+	)
 
-	return r.restartNodeBySystemdUnit(node, systemdUnitName, r.Opts.sshArgs)
+	return r.restartNodeBySystemdUnit(ctx, node, systemdUnitName, r.Opts.sshArgs)
 }
 
 func (r TenantSSHRestarter) Filter(spec FilterNodeParams, cluster ClusterNodesInfo) []*Ydb_Maintenance.Node {
@@ -47,7 +57,6 @@ func (r TenantSSHRestarter) Filter(spec FilterNodeParams, cluster ClusterNodesIn
 
 	filteredNodes := ExcludeByCommonFields(preSelectedNodes, spec)
 
-	fmt.Printf("%v\n", r)
 	r.logger.Debugf("Tenant SSH Restarter selected following nodes for restart: %+v", filteredNodes)
 
 	return filteredNodes
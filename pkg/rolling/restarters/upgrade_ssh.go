@@ -0,0 +1,209 @@
+package restarters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultBinaryLink = "/opt/ydb/bin/ydbd"
+
+	// files are replaced via a '.new' copy, the replaced one is kept as '.previous'
+	previousSuffix = ".previous"
+	newSuffix      = ".new"
+)
+
+// UpgradeSSHRestarter installs a new ydbd binary on a node before restarting
+// it with the wrapped restarter, and rolls the binary back if the node does not
+// come back with the expected version.
+type UpgradeSSHRestarter struct {
+	sshRestarter
+
+	Opts *UpgradeSSHOpts
+
+	restarter Restarter
+	runRemote remoteRunner
+	hosts     hostLocks
+
+	mu sync.Mutex
+	// upgraded are the nodes that have come back with the target version,
+	// by host. The binary link of their host must not be rolled back.
+	upgraded map[string][]uint32
+}
+
+func NewUpgradeSSHRestarter(logger *zap.SugaredLogger, sshArgs []string, restarter Restarter, opts *UpgradeSSHOpts) *UpgradeSSHRestarter {
+	opts.sshOpts = sshOpts{
+		sshArgs: sshArgs,
+	}
+	if opts.BinaryLink == "" {
+		opts.BinaryLink = DefaultBinaryLink
+	}
+
+	r := &UpgradeSSHRestarter{
+		Opts:         opts,
+		restarter:    restarter,
+		sshRestarter: newSSHRestarter(logger),
+	}
+	r.runRemote = func(ctx context.Context, host, command string, stdin io.Reader, stdout io.Writer) error {
+		return r.runRemoteCommand(ctx, host, command, r.Opts.sshArgs, stdin, stdout, nil)
+	}
+	return r
+}
+
+func (r *UpgradeSSHRestarter) Filter(spec FilterNodeParams, cluster ClusterNodesInfo) []*Ydb_Maintenance.Node {
+	return r.restarter.Filter(spec, cluster)
+}
+
+func (r *UpgradeSSHRestarter) RestartNode(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	r.logger.Infof("Installing ydbd %s on %s", r.Opts.TargetVersion, node.Host)
+
+	if err := r.installBinary(ctx, node.Host); err != nil {
+		return fmt.Errorf("failed to install the binary on %s: %w", node.Host, err)
+	}
+
+	err := r.restarter.RestartNode(ctx, node)
+	if err == nil {
+		err = r.waitForVersion(ctx, node)
+	}
+	if err == nil {
+		r.rememberUpgraded(node)
+		return nil
+	}
+
+	r.logger.Warnf("Upgrade of node %d failed, rolling back to the previous binary: %v", node.NodeId, err)
+	rollbackCtx, cancel := rollbackContext(ctx)
+	defer cancel()
+	if rollbackErr := r.rollback(rollbackCtx, node); rollbackErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to roll back the binary: %w", rollbackErr))
+	}
+	return err
+}
+
+// installBinary puts the new binary next to the link, checks its checksum,
+// saves the current link and atomically switches it to the new binary.
+// Nodes of a host share the link, they are installed one at a time.
+func (r *UpgradeSSHRestarter) installBinary(ctx context.Context, host string) error {
+	defer r.hosts.lock(host)()
+
+	link := r.Opts.BinaryLink
+	binary := path.Join(path.Dir(link), "ydbd-"+r.Opts.TargetVersion)
+	staged := binary + newSuffix
+
+	var stdin io.Reader
+	var stageCommand string
+	if IsURL(r.Opts.Binary) {
+		stageCommand = fmt.Sprintf("sudo curl -fsSL -o %s '%s'", staged, r.Opts.Binary)
+	} else {
+		f, err := os.Open(r.Opts.Binary)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		stdin = f
+		stageCommand = fmt.Sprintf("sudo tee %s > /dev/null", staged)
+	}
+
+	if err := r.runRemote(ctx, host, stageCommand, stdin, nil); err != nil {
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+
+	verifyCommand := fmt.Sprintf("echo '%s  %s' | sha256sum --check --status -", r.Opts.Checksum, staged)
+	if err := r.runRemote(ctx, host, verifyCommand, nil, nil); err != nil {
+		return fmt.Errorf("checksum mismatch: %w", err)
+	}
+
+	switchCommand := strings.Join([]string{
+		fmt.Sprintf("sudo chmod +x %s", staged),
+		fmt.Sprintf("sudo mv -f %s %s", staged, binary),
+		// a retry after a failed rollback must not overwrite the saved link
+		fmt.Sprintf("(test %s -ef %s || sudo cp -P %s %s)", link, binary, link, link+previousSuffix),
+		fmt.Sprintf("sudo ln -sfn %s %s", binary, link+newSuffix),
+		fmt.Sprintf("sudo mv -T %s %s", link+newSuffix, link),
+	}, " && ")
+	if err := r.runRemote(ctx, host, switchCommand, nil, nil); err != nil {
+		return fmt.Errorf("failed to switch %s: %w", link, err)
+	}
+
+	return nil
+}
+
+// rollback switches the link of the host back to the previous binary and
+// restarts the node. The link is shared by the nodes of the host, so it is
+// left as is once another node of the host runs the new binary: that node
+// would start the previous one on its next restart.
+func (r *UpgradeSSHRestarter) rollback(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	link := r.Opts.BinaryLink
+	rollbackCommand := strings.Join([]string{
+		fmt.Sprintf("sudo cp -P %s %s", link+previousSuffix, link+newSuffix),
+		fmt.Sprintf("sudo mv -T %s %s", link+newSuffix, link),
+	}, " && ")
+	unlock := r.hosts.lock(node.Host)
+	if upgraded := r.upgradedOn(node.Host); len(upgraded) > 0 {
+		unlock()
+		return fmt.Errorf(
+			"nodes %v of %s already run ydbd %s, %s is left switched to it",
+			upgraded, node.Host, r.Opts.TargetVersion, link,
+		)
+	}
+	err := r.runRemote(ctx, node.Host, rollbackCommand, nil, nil)
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	return r.restarter.RestartNode(ctx, node)
+}
+
+func (r *UpgradeSSHRestarter) rememberUpgraded(node *Ydb_Maintenance.Node) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.upgraded == nil {
+		r.upgraded = map[string][]uint32{}
+	}
+	r.upgraded[node.Host] = append(r.upgraded[node.Host], node.NodeId)
+}
+
+func (r *UpgradeSSHRestarter) upgradedOn(host string) []uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]uint32{}, r.upgraded[host]...)
+}
+
+func (r *UpgradeSSHRestarter) waitForVersion(ctx context.Context, node *Ydb_Maintenance.Node) error {
+	err := waitUntil(ctx, r.Opts.VersionTimeout, r.Opts.VersionPollInterval, func() error {
+		version, err := r.Opts.VersionOf(node)
+		if err != nil {
+			return err
+		}
+		if version != r.Opts.TargetVersion {
+			return fmt.Errorf("reported version %q", version)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf(
+			"node %d did not report version %s in %s: %w",
+			node.NodeId, r.Opts.TargetVersion, r.Opts.VersionTimeout, err,
+		)
+	}
+
+	r.logger.Infof("Node %d reports version %s", node.NodeId, r.Opts.TargetVersion)
+	return nil
+}
+
+// IsURL tells if the binary is downloaded by the nodes instead of copied to them.
+func IsURL(binary string) bool {
+	return strings.HasPrefix(binary, "http://") || strings.HasPrefix(binary, "https://")
+}
@@ -0,0 +1,25 @@
+package restarters
+
+import (
+	"time"
+
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+)
+
+type UpgradeSSHOpts struct {
+	sshOpts
+
+	// Binary is a local path or an http(s) URL the nodes download the binary from
+	Binary string
+	// Checksum is the expected sha256 of the binary, hex encoded
+	Checksum      string
+	TargetVersion string
+	// BinaryLink is the symlink systemd units start ydbd by
+	BinaryLink string
+
+	VersionTimeout      time.Duration
+	VersionPollInterval time.Duration
+
+	// VersionOf reports the version of a node as CMS sees it
+	VersionOf func(node *Ydb_Maintenance.Node) (string, error)
+}
@@ -0,0 +1,178 @@
+package restarters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/tests/mock"
+)
+
+var _ = Describe("Test upgrade ssh restarter", func() {
+	var (
+		node             *Ydb_Maintenance.Node
+		restarted        []uint32
+		remoteCommands   []string
+		remoteCtxErrs    []error
+		reportedVersion  string
+		failingRemoteCmd string
+		restarter        *UpgradeSSHRestarter
+	)
+
+	BeforeEach(func() {
+		node = mock.CreateNodesFromShortConfig([][]uint32{{1}}, nil)[0]
+		restarted = nil
+		remoteCommands = nil
+		remoteCtxErrs = nil
+		reportedVersion = "24.1.1"
+		failingRemoteCmd = ""
+
+		restarter = NewUpgradeSSHRestarter(
+			zap.S(),
+			[]string{},
+			recordingRestarter{NewStorageSSHRestarter(zap.S(), []string{}, ""), &restarted},
+			&UpgradeSSHOpts{
+				Binary:        "https://example.com/ydbd",
+				Checksum:      "abcdef",
+				TargetVersion: "24.1.1",
+				VersionOf: func(*Ydb_Maintenance.Node) (string, error) {
+					return reportedVersion, nil
+				},
+			},
+		)
+		restarter.runRemote = func(ctx context.Context, _, command string, _ io.Reader, _ io.Writer) error {
+			remoteCommands = append(remoteCommands, command)
+			remoteCtxErrs = append(remoteCtxErrs, ctx.Err())
+			if failingRemoteCmd != "" && strings.Contains(command, failingRemoteCmd) {
+				return errors.New("remote command failed")
+			}
+			return nil
+		}
+	})
+
+	It("downloads, verifies and switches the binary before restarting the node", func() {
+		Expect(restarter.RestartNode(context.Background(), node)).To(Succeed())
+
+		Expect(remoteCommands).To(HaveLen(3))
+		Expect(remoteCommands[0]).To(ContainSubstring("curl -fsSL -o /opt/ydb/bin/ydbd-24.1.1.new 'https://example.com/ydbd'"))
+		Expect(remoteCommands[1]).To(ContainSubstring("echo 'abcdef  /opt/ydb/bin/ydbd-24.1.1.new' | sha256sum"))
+		Expect(remoteCommands[2]).To(ContainSubstring("sudo mv -T /opt/ydb/bin/ydbd.new /opt/ydb/bin/ydbd"))
+		Expect(restarted).To(Equal([]uint32{1}))
+	})
+
+	It("does not restart the node if the checksum does not match", func() {
+		failingRemoteCmd = "sha256sum"
+
+		Expect(restarter.RestartNode(context.Background(), node)).NotTo(Succeed())
+
+		Expect(remoteCommands).To(HaveLen(2))
+		Expect(restarted).To(BeEmpty())
+	})
+
+	It("rolls back the binary if the node reports another version", func() {
+		reportedVersion = "23.4.1"
+
+		err := restarter.RestartNode(context.Background(), node)
+		Expect(err).To(MatchError(ContainSubstring("did not report version 24.1.1")))
+
+		Expect(remoteCommands).To(HaveLen(4))
+		Expect(remoteCommands[3]).To(Equal(
+			"sudo cp -P /opt/ydb/bin/ydbd.previous /opt/ydb/bin/ydbd.new && sudo mv -T /opt/ydb/bin/ydbd.new /opt/ydb/bin/ydbd",
+		))
+		Expect(restarted).To(Equal([]uint32{1, 1}))
+	})
+
+	It("rolls back the binary when the restart times out waiting for the version", func() {
+		reportedVersion = "23.4.1"
+		restarter.Opts.VersionTimeout = time.Minute
+		restarter.Opts.VersionPollInterval = 10 * time.Millisecond
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := restarter.RestartNode(ctx, node)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+
+		Expect(remoteCommands).To(HaveLen(4))
+		Expect(remoteCommands[3]).To(HavePrefix("sudo cp -P /opt/ydb/bin/ydbd.previous"))
+		Expect(remoteCtxErrs[3]).ToNot(HaveOccurred())
+		Expect(restarted).To(Equal([]uint32{1, 1}))
+	})
+
+	It("does not roll back the binary of a host where another node has been upgraded", func() {
+		nodes := mock.CreateNodesFromShortConfig([][]uint32{{1, 2}}, nil)
+		nodes[1].Host = nodes[0].Host
+
+		Expect(restarter.RestartNode(context.Background(), nodes[0])).To(Succeed())
+
+		reportedVersion = "23.4.1"
+		err := restarter.RestartNode(context.Background(), nodes[1])
+		Expect(err).To(MatchError(ContainSubstring("did not report version 24.1.1")))
+		Expect(err).To(MatchError(ContainSubstring(
+			"failed to roll back the binary: nodes [1] of " + nodes[0].Host + " already run ydbd 24.1.1",
+		)))
+
+		for _, command := range remoteCommands {
+			Expect(command).ToNot(ContainSubstring("ydbd.previous /opt/ydb/bin/ydbd.new"))
+		}
+		Expect(restarted).To(Equal([]uint32{1, 2}))
+	})
+
+	It("installs the binary for one node of a host at a time", func() {
+		nodes := mock.CreateNodesFromShortConfig([][]uint32{{1, 2}}, nil)
+		nodes[1].Host = nodes[0].Host
+
+		var (
+			mu         sync.Mutex
+			installing bool
+			overlapped bool
+		)
+		restarter.restarter = noopRestarter{NewStorageSSHRestarter(zap.S(), []string{}, "")}
+		restarter.runRemote = func(_ context.Context, _, command string, _ io.Reader, _ io.Writer) error {
+			mu.Lock()
+			if strings.Contains(command, "curl") {
+				overlapped = overlapped || installing
+				installing = true
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			if strings.Contains(command, "sudo mv -T") {
+				installing = false
+			}
+			mu.Unlock()
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		for _, n := range nodes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				Expect(restarter.RestartNode(context.Background(), n)).To(Succeed())
+			}()
+		}
+		wg.Wait()
+
+		Expect(overlapped).To(BeFalse())
+	})
+})
+
+// noopRestarter restarts nothing, it is safe to use from several goroutines.
+type noopRestarter struct {
+	Restarter
+}
+
+func (r noopRestarter) RestartNode(context.Context, *Ydb_Maintenance.Node) error {
+	return nil
+}
@@ -17,10 +17,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/ydb-platform/ydbops/internal/collections"
 	"github.com/ydb-platform/ydbops/pkg/client/cms"
 	"github.com/ydb-platform/ydbops/pkg/client/discovery"
+	"github.com/ydb-platform/ydbops/pkg/notify"
 	"github.com/ydb-platform/ydbops/pkg/prettyprint"
 	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
 	"github.com/ydb-platform/ydbops/pkg/utils"
@@ -34,6 +36,7 @@ type Rolling struct {
 	state     *state
 	opts      *RestartOptions
 	restarter restarters.Restarter
+	hooks     *nodeHooks
 
 	// TODO jorres@: maybe turn this into a local `map`
 	// variable in `processActionGroupStates`
@@ -44,17 +47,33 @@ type Rolling struct {
 type MajorToMinors map[int]map[int]bool
 
 type state struct {
-	knownVersions                  MajorToMinors
-	nodes                          map[uint32]*Ydb_Maintenance.Node
-	inactiveNodes                  map[uint32]*Ydb_Maintenance.Node
-	tenantNameToNodeIds            map[string][]uint32
-	retriesMadeForNode             map[uint32]int
-	tenants                        []string
-	userSID                        string
-	unreportedButFinishedActionIds []string
-	restartTaskUID                 string
-	alreadyRestartedNodes          int
-	totalFilteredNodes             int
+	knownVersions         MajorToMinors
+	nodes                 map[uint32]*Ydb_Maintenance.Node
+	hostNodes             map[string][]*Ydb_Maintenance.Node
+	inactiveNodes         map[uint32]*Ydb_Maintenance.Node
+	tenantNameToNodeIds   map[string][]uint32
+	retriesMadeForScope   map[string]int
+	tenants               []string
+	userSID               string
+	finishedScopes        map[string]bool
+	restartTaskUID        string
+	nodeRank              map[uint32]int
+	alreadyRestartedNodes int
+	totalFilteredNodes    int
+	// skippedNodes are left as is by --pre-node-hook-failure skip
+	skippedNodes int
+	// failedNodes have failed all their attempts, see --max-failed-nodes
+	failedNodes int
+
+	// createdTaskUIDs are the tasks of this run, the only ones cleanup drops by default
+	createdTaskUIDs []string
+
+	// the budget of the current wave, see warnAboutLockBudget
+	waveStarted  time.Time
+	waveBudget   time.Duration
+	budgetWarned bool
+	// followUpTask holds the locks requested again, see requestExpiringLocksAgain
+	followUpTask cms.MaintenanceTask
 }
 
 const (
@@ -96,6 +115,14 @@ func (e *executer) Execute() error {
 		logger:    e.logger,
 		opts:      e.opts,
 		restarter: e.restarter,
+		hooks: &nodeHooks{
+			logger: e.logger,
+			opts:   e.opts,
+		},
+	}
+
+	if e.opts.PlanOut != "" {
+		return r.writePlanPhase()
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -126,6 +153,14 @@ func (e *executer) Execute() error {
 			e.logger.Info("Successfully cleaned up maintenance tasks")
 		}
 		return err
+	} else if errors.Is(err, errFailureBudgetExceeded) {
+		e.logger.Errorf("Failed to complete restart: %+v", err)
+		e.logger.Info("Cleaning up maintenance tasks of the stopped restart")
+
+		if cleanupErr := r.cleanupRollingRestart(); cleanupErr != nil {
+			e.logger.Errorf("Failed to cleanup maintenance tasks of the stopped restart: %v", cleanupErr)
+		}
+		return err
 	} else if err != nil {
 		e.logger.Errorf("Failed to complete restart: %+v", err)
 		return err
@@ -146,10 +181,42 @@ func (r *Rolling) DoRestart(ctx context.Context) error {
 		return err
 	}
 
+	var waves []wave
+	if r.opts.plan != nil {
+		waves, err = r.replayPlanPhase()
+	} else {
+		waves, err = r.selectWaves()
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(waves) == 0 {
+		r.logger.Warn("There are no nodes that satisfy the specified filters")
+		return nil
+	}
+
+	for i, w := range waves {
+		if len(waves) > 1 {
+			r.logger.Infof("Restarting %s (%d nodes), %d out of %d", w.name, len(w.nodes), i+1, len(waves))
+			r.state.restartTaskUID = RestartTaskPrefix + uuid.New().String()
+		}
+
+		if err = r.restartWave(ctx, w.nodes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selectWaves filters the nodes to restart and puts them in order. Returns
+// no waves if there is nothing to restart.
+func (r *Rolling) selectWaves() ([]wave, error) {
 	nodeIds, errIds := utils.GetNodeIds(r.opts.Hosts)
 	nodeFQDNs, errFqdns := utils.GetNodeFQDNs(r.opts.Hosts)
 	if errIds != nil && errFqdns != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"TODO parsing both in id mode and in fqdn mode failed: (%w), (%w)",
 			errIds,
 			errFqdns,
@@ -186,59 +253,123 @@ func (r *Rolling) DoRestart(ctx context.Context) error {
 	}
 
 	if len(nodesToRestart)-excludedNodes == 0 {
-		r.logger.Warn("There are no nodes that satisfy the specified filters")
-		return nil
+		return nil, nil
 	}
 
-	taskParams := cms.MaintenanceTaskParams{
-		TaskUID:          r.state.restartTaskUID,
-		AvailabilityMode: r.opts.GetAvailabilityMode(),
-		Priority:         int32(r.opts.Priority),
-		Duration:         r.opts.GetRestartDuration(len(nodesToRestart)),
-		ScopeType:        cms.NodeScope,
-		Nodes:            nodesToRestart,
+	r.state.totalFilteredNodes = len(nodesToRestart)
+
+	if err := r.orderNodes(nodesToRestart); err != nil {
+		return nil, err
 	}
 
+	return r.splitIntoWaves(nodesToRestart), nil
+}
+
+func (r *Rolling) restartWave(ctx context.Context, nodes []*Ydb_Maintenance.Node) error {
+	if r.opts.HostLevel {
+		r.rememberHostNodes(nodes)
+	}
+	taskParams := r.taskParams(r.state.restartTaskUID, nodes)
+
+	// remembered before the request: the task may be created even if the response is lost
+	r.state.createdTaskUIDs = append(r.state.createdTaskUIDs, taskParams.TaskUID)
+
 	task, err := r.cms.CreateMaintenanceTask(taskParams)
 	if err != nil {
 		return fmt.Errorf("failed to create maintenance task: %w", err)
 	}
 
-	r.state.totalFilteredNodes = len(nodesToRestart)
-
+	r.startWave(taskParams.Duration.AsDuration())
 	return r.cmsWaitingLoop(ctx, task)
 }
 
+// taskParams requests the locks of the nodes, or of their hosts with --host-level.
+func (r *Rolling) taskParams(taskUID string, nodes []*Ydb_Maintenance.Node) cms.MaintenanceTaskParams {
+	groupKey := r.opts.NodeGroupKey()
+	taskParams := cms.MaintenanceTaskParams{
+		TaskUID:          taskUID,
+		AvailabilityMode: r.opts.GetAvailabilityMode(),
+		Priority:         int32(r.opts.Priority),
+		Duration:         r.opts.GetRestartDuration(countGroups(nodes, groupKey)),
+		ScopeType:        cms.NodeScope,
+		Nodes:            nodes,
+		GroupKey:         groupKey,
+	}
+
+	if r.opts.HostLevel {
+		hosts := []string{}
+		for _, node := range nodes {
+			if !slices.Contains(hosts, node.GetHost()) {
+				hosts = append(hosts, node.GetHost())
+			}
+		}
+
+		// nodes of a host are restarted one after another
+		maxNodesPerHost := 0
+		for _, host := range hosts {
+			maxNodesPerHost = max(maxNodesPerHost, len(r.state.hostNodes[host]))
+		}
+		duration := r.opts.GetRestartDuration(len(hosts)).AsDuration() * time.Duration(maxNodesPerHost)
+
+		taskParams.ScopeType = cms.HostScope
+		taskParams.Hosts = hosts
+		taskParams.Nodes = nil
+		taskParams.GroupKey = nil
+		taskParams.Duration = durationpb.New(duration)
+	}
+
+	return taskParams
+}
+
 func (r *Rolling) cmsWaitingLoop(ctx context.Context, task cms.MaintenanceTask) error {
 	var (
-		err          error
-		delay        time.Duration
-		taskID       = task.GetTaskUid()
-		defaultDelay = time.Duration(r.opts.CMSQueryInterval) * time.Second
+		err    error
+		delay  time.Duration
+		taskID = task.GetTaskUid()
+		poll   = newPollPolicy(
+			time.Duration(r.opts.CMSQueryInterval)*time.Second,
+			time.Duration(r.opts.CMSQueryMaxInterval)*time.Second,
+		)
 	)
 
 	r.logger.Infof("Maintenance task %v, processing loop started", taskID)
 	for {
-		delay = defaultDelay
+		var (
+			completed, progressed bool
+			retryAfter            time.Time
+		)
 
 		if task != nil {
 			r.logTask(task)
 
 			if task.GetRetryAfter() != nil {
-				retryTime := task.GetRetryAfter().AsTime()
-				r.logger.Debugf("Task has retry after attribute: %s", retryTime.Format(time.DateTime))
-
-				if retryDelay := retryTime.Sub(time.Now().UTC()); defaultDelay < retryDelay {
-					delay = defaultDelay
-				}
+				retryAfter = task.GetRetryAfter().AsTime()
+				r.logger.Debugf("Task has retry after attribute: %s", retryAfter.Format(time.DateTime))
 			}
 
-			if completed := r.processActionGroupStates(ctx, task.GetActionGroupStates()); completed {
+			completed, progressed = r.processActionGroupStates(ctx, task.GetActionGroupStates())
+			if err = r.checkFailureBudget(task.GetTaskUid()); err != nil {
+				return err
+			}
+			if completed {
 				break
 			}
+			if r.state.followUpTask != nil {
+				task = r.switchToFollowUp(taskID)
+				taskID = task.GetTaskUid()
+				continue
+			}
 		}
 
-		r.logger.Infof("Wait next %s delay before refreshing maintenance task in CMS", delay)
+		if progressed {
+			delay = poll.progressed()
+		} else {
+			delay = poll.idle(retryAfter, time.Now().UTC())
+		}
+
+		if delay > 0 {
+			r.logger.Infof("Wait next %s delay before refreshing maintenance task in CMS", delay)
+		}
 
 		if err = waitOrCancel(ctx, delay); err != nil {
 			return err
@@ -268,6 +399,10 @@ func (r *Rolling) cmsWaitingLoop(ctx context.Context, task cms.MaintenanceTask)
 			// if error is retryExceeded, just keep trying - maybe you have been asking CMS
 			// from a node that has just been restarted, and it's okay.
 			if incompatible != nil && !errors.Is(incompatible, &utils.RetryExceededError{}) {
+				event := notify.NewEvent(notify.EventCompatCheckFailed, "Compatibility check stopped the rolling restart")
+				event.TaskUID = taskID
+				event.Error = incompatible.Error()
+				r.opts.Notify.Notifier().Notify(event)
 				return incompatible
 			}
 		}
@@ -286,45 +421,98 @@ func (r *Rolling) handleRestartStatus(ctx context.Context, statuses <-chan resta
 			return
 		case st := <-statuses:
 			if st.err == nil {
-				r.atomicRememberComplete(st.as.GetActionUid())
+				r.atomicRememberComplete(st.as, st.skipped)
 				continue
 			}
 
-			retriesUntilNow := r.state.retriesMadeForNode[st.nodeID]
-			r.state.retriesMadeForNode[st.nodeID]++
+			target := cms.ScopeToString(st.as.GetAction().GetLockAction().GetScope())
+			r.mu.Lock()
+			retriesUntilNow := r.state.retriesMadeForScope[target]
+			r.state.retriesMadeForScope[target]++
+			r.mu.Unlock()
 
 			r.logger.Warnf(
-				"Failed to restart node with id: %d, attempt number %v, because of: %s",
-				st.nodeID,
+				"Failed to restart %s, attempt number %v, because of: %s",
+				target,
 				retriesUntilNow,
 				st.err.Error(),
 			)
 
+			if errors.Is(st.err, restarters.ErrNotRetryable) {
+				r.atomicRememberComplete(st.as, 0)
+				r.logger.Warnf("Failed to restart %s with an error that can not be retried, skipping remaining attempts", target)
+				r.giveUp(st, target)
+				continue
+			}
+
 			if retriesUntilNow+1 == r.opts.RestartRetryNumber {
-				r.atomicRememberComplete(st.as.GetActionUid())
-				r.logger.Warnf("Failed to retry node %v specified number of times (%v)", st.nodeID, r.opts.RestartRetryNumber)
+				r.atomicRememberComplete(st.as, 0)
+				r.logger.Warnf("Failed to retry %s specified number of times (%v)", target, r.opts.RestartRetryNumber)
+				r.giveUp(st, target)
 			}
 		}
 	}
 }
 
+// giveUp counts the node against --max-failed-nodes and reports it.
+func (r *Rolling) giveUp(st restartStatus, target string) {
+	r.mu.Lock()
+	r.state.failedNodes++
+	r.mu.Unlock()
+
+	event := notify.NewEvent(notify.EventNodeFailed, fmt.Sprintf("Gave up restarting %s", target))
+	event.TaskUID = st.as.GetActionUid().GetTaskUid()
+	event.NodeID, _ = cms.ScopeNodeAndHost(st.as.GetAction().GetLockAction().GetScope())
+	event.Error = st.err.Error()
+	r.opts.Notify.Notifier().Notify(event)
+}
+
+// errFailureBudgetExceeded stops the restart, Execute drops the tasks of the
+// restart so that the nodes waiting for their turn are not left locked.
+var errFailureBudgetExceeded = errors.New("stopping the restart")
+
+// checkFailureBudget stops the restart once --max-failed-nodes nodes have failed.
+func (r *Rolling) checkFailureBudget(taskUID string) error {
+	r.mu.RLock()
+	failed := r.state.failedNodes
+	r.mu.RUnlock()
+
+	if r.opts.MaxFailedNodes == 0 || failed < r.opts.MaxFailedNodes {
+		return nil
+	}
+
+	err := fmt.Errorf("%d nodes have failed to restart, --max-failed-nodes is %d, %w",
+		failed, r.opts.MaxFailedNodes, errFailureBudgetExceeded)
+	event := notify.NewEvent(notify.EventBudgetExceeded, "Too many failed nodes stopped the rolling restart")
+	event.TaskUID = taskUID
+	event.Error = err.Error()
+	event.FailedNodes = failed
+	event.MaxFailedNodes = r.opts.MaxFailedNodes
+	r.opts.Notify.Notifier().Notify(event)
+	return err
+}
+
 func (r *Rolling) getPerformedActions(actions []*Ydb_Maintenance.ActionGroupStates) []*Ydb_Maintenance.ActionGroupStates {
 	r.logger.Debugf("Unfiltered ActionGroupStates: %v", actions)
 
 	var actionStatesBuf bytes.Buffer
 	performed := collections.FilterBy(actions,
 		func(gs *Ydb_Maintenance.ActionGroupStates) bool {
-			st := gs.ActionStates[0]
-			if st.Status == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
-				return true
-			}
+			// CMS grants actions of a group together, restart the group only when all of them are granted
+			groupPerformed := true
+			for _, st := range gs.ActionStates {
+				if st.Status == Ydb_Maintenance.ActionState_ACTION_STATUS_PERFORMED {
+					continue
+				}
+				groupPerformed = false
 
-			details := st.GetDetails()
-			if details != "" {
-				actionStatesBuf.WriteString(st.GetDetails())
-				actionStatesBuf.WriteString("\n")
+				details := st.GetDetails()
+				if details != "" {
+					actionStatesBuf.WriteString(st.GetDetails())
+					actionStatesBuf.WriteString("\n")
+				}
 			}
-			return false
+			return groupPerformed
 		},
 	)
 
@@ -340,13 +528,16 @@ func (r *Rolling) getPerformedActions(actions []*Ydb_Maintenance.ActionGroupStat
 	return performed
 }
 
-func (r *Rolling) processActionGroupStates(ctx context.Context, actions []*Ydb_Maintenance.ActionGroupStates) bool {
+// processActionGroupStates restarts nodes of the performed actions and reports them to CMS.
+// It tells if all actions of the task are completed and if any were completed just now.
+func (r *Rolling) processActionGroupStates(ctx context.Context, actions []*Ydb_Maintenance.ActionGroupStates) (bool, bool) {
 	performed := r.getPerformedActions(actions)
 	if len(performed) == 0 {
-		return false
+		return false, false
 	}
 
 	r.logger.Infof("%d ActionGroupStates moved to PERFORMED, will restart now...", len(performed))
+	r.sortByRank(performed)
 
 	r.completedActions = []*Ydb_Maintenance.ActionUid{}
 
@@ -361,26 +552,41 @@ func (r *Rolling) processActionGroupStates(ctx context.Context, actions []*Ydb_M
 	filteredActions := make([]*Ydb_Maintenance.ActionGroupStates, 0, len(performed))
 	expectedRestarts := 0
 	for _, gs := range performed {
-		as := gs.ActionStates[0]
-		lock := as.Action.GetLockAction()
-		if lock == nil {
-			panic(fmt.Sprintf("unexpected non-lock action type in processActionGroupStates: %v", as.Action))
+		toRestart := &Ydb_Maintenance.ActionGroupStates{}
+		for _, as := range gs.ActionStates {
+			lock := as.Action.GetLockAction()
+			if lock == nil {
+				panic(fmt.Sprintf("unexpected non-lock action type in processActionGroupStates: %v", as.Action))
+			}
+			if r.atomicIsFinished(lock.Scope) {
+				r.mu.Lock()
+				r.completedActions = append(r.completedActions, as.ActionUid)
+				r.mu.Unlock()
+
+				r.logger.Debugf(
+					"%s already restarted, but CompleteAction failed on last iteration, "+
+						"so CMS does not know it is complete yet.",
+					cms.ScopeToString(lock.Scope),
+				)
+				continue
+			}
+			toRestart.ActionStates = append(toRestart.ActionStates, as)
 		}
-		node := r.state.nodes[lock.Scope.GetNodeId()]
-		if r.atomicHasActionInUnreported(as.GetActionUid().GetActionId()) {
-			r.mu.Lock()
-			r.completedActions = append(r.completedActions, as.ActionUid)
-			r.mu.Unlock()
 
-			r.logger.Debugf(
-				"Node id %v already restarted, but CompleteAction failed on last iteration, "+
-					"so CMS does not know it is complete yet.",
-				node.NodeId,
-			)
+		if len(toRestart.ActionStates) == 0 {
 			continue
 		}
-		expectedRestarts++
-		filteredActions = append(filteredActions, gs)
+		expectedRestarts += len(toRestart.ActionStates)
+		filteredActions = append(filteredActions, toRestart)
+	}
+
+	r.warnAboutLockBudget(actions)
+	if r.opts.ExtendLocks {
+		filteredActions = r.requestExpiringLocksAgain(actions, filteredActions)
+		expectedRestarts = 0
+		for _, gs := range filteredActions {
+			expectedRestarts += len(gs.ActionStates)
+		}
 	}
 
 	go func() {
@@ -395,14 +601,17 @@ func (r *Rolling) processActionGroupStates(ctx context.Context, actions []*Ydb_M
 	result, err := r.cms.CompleteAction(r.completedActions)
 	if err != nil {
 		r.logger.Warnf("Failed to complete action: %+v", err)
-		return false
+		return false, false
 	}
 	r.logCompleteResult(result)
-	r.state.unreportedButFinishedActionIds = []string{}
 
-	restartCompleted := len(actions) == len(result.ActionStatuses)
+	totalActions := 0
+	for _, gs := range actions {
+		totalActions += len(gs.ActionStates)
+	}
+	restartCompleted := totalActions == len(result.ActionStatuses)
 
-	return restartCompleted
+	return restartCompleted, len(result.ActionStatuses) > 0
 }
 
 func (r *Rolling) dispatchActions(ctx context.Context, actions []*Ydb_Maintenance.ActionGroupStates, statusCh chan restartStatus) {
@@ -425,9 +634,12 @@ func (r *Rolling) dispatchAll(ctx context.Context, actions []*Ydb_Maintenance.Ac
 		ctx,
 		r.logger,
 		r.restarter,
+		r.hooks,
 		r.opts.NodesInflight,
 		r.opts.DelayBetweenRestarts,
-		r.state.nodes,
+		r.opts.NodeRestartTimeout,
+		r.nodesOf,
+		r.atomicAttemptOf,
 		statusCh,
 	)
 	handler.run()
@@ -464,9 +676,12 @@ func (r *Rolling) dispatchByTenant(ctx context.Context, actions []*Ydb_Maintenan
 				ctx,
 				r.logger,
 				r.restarter,
+				r.hooks,
 				r.opts.NodesInflight,
 				r.opts.DelayBetweenRestarts,
-				r.state.nodes,
+				r.opts.NodeRestartTimeout,
+				r.nodesOf,
+				r.atomicAttemptOf,
 				statusCh,
 			)
 			handler.run()
@@ -482,21 +697,45 @@ func (r *Rolling) dispatchByTenant(ctx context.Context, actions []*Ydb_Maintenan
 	wg.Wait()
 }
 
-func (r *Rolling) atomicHasActionInUnreported(actionID string) bool {
+func (r *Rolling) atomicIsFinished(scope *Ydb_Maintenance.ActionScope) bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	return collections.Contains(r.state.unreportedButFinishedActionIds, actionID)
+	return r.state.finishedScopes[cms.ScopeToString(scope)]
 }
 
-func (r *Rolling) atomicRememberComplete(actionUID *Ydb_Maintenance.ActionUid) {
+func (r *Rolling) atomicAttemptOf(as *Ydb_Maintenance.ActionState) restarters.RestartAttempt {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	target := cms.ScopeToString(as.GetAction().GetLockAction().GetScope())
+	return restarters.RestartAttempt{
+		TaskUID: as.GetActionUid().GetTaskUid(),
+		Number:  r.state.retriesMadeForScope[target] + 1,
+	}
+}
+
+// atomicRememberComplete marks the action to be completed. skipped nodes of
+// the action have not been restarted and are not counted as restarted.
+func (r *Rolling) atomicRememberComplete(as *Ydb_Maintenance.ActionState, skipped int) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.state.unreportedButFinishedActionIds = append(r.state.unreportedButFinishedActionIds, actionUID.ActionId)
-	r.completedActions = append(r.completedActions, actionUID)
-	r.state.alreadyRestartedNodes++
-	r.logger.Infof("Total node progress: %v out of %v", r.state.alreadyRestartedNodes, r.state.totalFilteredNodes)
+	r.state.finishedScopes[cms.ScopeToString(as.GetAction().GetLockAction().GetScope())] = true
+	r.completedActions = append(r.completedActions, as.GetActionUid())
+	r.state.alreadyRestartedNodes += len(r.nodesOf(as.GetAction().GetLockAction().GetScope())) - skipped
+	r.state.skippedNodes += skipped
+
+	if r.state.skippedNodes == 0 {
+		r.logger.Infof("Total node progress: %v out of %v", r.state.alreadyRestartedNodes, r.state.totalFilteredNodes)
+		return
+	}
+	r.logger.Infof(
+		"Total node progress: %v out of %v, %v skipped",
+		r.state.alreadyRestartedNodes,
+		r.state.totalFilteredNodes,
+		r.state.skippedNodes,
+	)
 }
 
 func (r *Rolling) prepareState() (*state, error) {
@@ -530,30 +769,59 @@ func (r *Rolling) prepareState() (*state, error) {
 	}
 
 	return &state{
-		knownVersions:                  make(MajorToMinors),
-		tenantNameToNodeIds:            utils.PopulateTenantToNodesMapping(activeNodes),
-		tenants:                        tenants,
-		userSID:                        userSID,
-		nodes:                          collections.ToMap(activeNodes, func(n *Ydb_Maintenance.Node) uint32 { return n.NodeId }),
-		inactiveNodes:                  collections.ToMap(inactiveNodes, func(n *Ydb_Maintenance.Node) uint32 { return n.NodeId }),
-		retriesMadeForNode:             make(map[uint32]int),
-		unreportedButFinishedActionIds: []string{},
-		restartTaskUID:                 RestartTaskPrefix + uuid.New().String(),
-		alreadyRestartedNodes:          0,
-		totalFilteredNodes:             0,
+		knownVersions:         make(MajorToMinors),
+		tenantNameToNodeIds:   utils.PopulateTenantToNodesMapping(activeNodes),
+		tenants:               tenants,
+		userSID:               userSID,
+		nodes:                 collections.ToMap(activeNodes, func(n *Ydb_Maintenance.Node) uint32 { return n.NodeId }),
+		inactiveNodes:         collections.ToMap(inactiveNodes, func(n *Ydb_Maintenance.Node) uint32 { return n.NodeId }),
+		hostNodes:             make(map[string][]*Ydb_Maintenance.Node),
+		retriesMadeForScope:   make(map[string]int),
+		finishedScopes:        make(map[string]bool),
+		restartTaskUID:        RestartTaskPrefix + uuid.New().String(),
+		alreadyRestartedNodes: 0,
+		totalFilteredNodes:    0,
 	}, nil
 }
 
+// cleanupRollingRestart drops the tasks created by this run and, with
+// --drop-stale-rolling-tasks, tasks left by previous rolling restarts of the user.
+// Other tasks of the user, e.g. created with `maintenance create`, are kept:
+// the user SID may be a service account shared with colleagues.
 func (r *Rolling) cleanupRollingRestart() error {
-	r.logger.Debugf("Will cleanup all maintenance tasks...")
+	r.logger.Debugf("Will cleanup maintenance tasks of this rolling restart...")
 
 	previousTasks, err := r.cms.MaintenanceTasks(r.state.userSID)
 	if err != nil {
 		return fmt.Errorf("failed to list maintenance tasks with user id %v: %w", r.state.userSID, err)
 	}
 
-	for _, previousTaskUID := range previousTasks {
-		_, err := r.cms.DropMaintenanceTask(previousTaskUID.GetTaskUid())
+	toDrop := []string{}
+	for _, task := range previousTasks {
+		taskUID := task.GetTaskUid()
+		switch {
+		case collections.Contains(r.state.createdTaskUIDs, taskUID):
+			toDrop = append(toDrop, taskUID)
+		case !strings.HasPrefix(taskUID, RestartTaskPrefix):
+			r.logger.Debugf("Keeping maintenance task %s, it was not created by a rolling restart", taskUID)
+		case r.opts.DropStaleRollingTasks:
+			toDrop = append(toDrop, taskUID)
+		default:
+			r.logger.Warnf(
+				"Maintenance task %s is left by another rolling restart and may hold locks. "+
+					"Drop it with --drop-stale-rolling-tasks if that restart is not running anymore",
+				taskUID,
+			)
+		}
+	}
+
+	if len(toDrop) == 0 {
+		return nil
+	}
+
+	r.logger.Infof("Will drop %d maintenance tasks: %s", len(toDrop), strings.Join(toDrop, ", "))
+	for _, taskUID := range toDrop {
+		_, err := r.cms.DropMaintenanceTask(taskUID)
 		if err != nil {
 			return fmt.Errorf("failed to drop maintenance task: %w", err)
 		}
@@ -561,24 +829,82 @@ func (r *Rolling) cleanupRollingRestart() error {
 	return nil
 }
 
+// isStorageNodeActionGroupState tells if a group has at least one storage node.
 func (r *Rolling) isStorageNodeActionGroupState(gs *Ydb_Maintenance.ActionGroupStates) bool {
-	as := gs.ActionStates[0]
-	lock := as.Action.GetLockAction()
-	if lock == nil {
-		panic(fmt.Sprintf("unexpected non-lock action type in isStorageNodeActionGroupState: %v", as.Action))
+	for _, as := range gs.ActionStates {
+		lock := as.Action.GetLockAction()
+		if lock == nil {
+			panic(fmt.Sprintf("unexpected non-lock action type in isStorageNodeActionGroupState: %v", as.Action))
+		}
+		for _, node := range r.nodesOf(lock.Scope) {
+			if node.GetDynamic() == nil {
+				return true
+			}
+		}
 	}
-	node := r.state.nodes[lock.Scope.GetNodeId()]
-	return node.GetDynamic() == nil
+	return false
 }
 
+// getStateNodeTenant returns the tenant of the first node of a group.
 func (r *Rolling) getStateNodeTenant(gs *Ydb_Maintenance.ActionGroupStates) string {
 	as := gs.ActionStates[0]
 	lock := as.Action.GetLockAction()
 	if lock == nil {
 		panic(fmt.Sprintf("unexpected non-lock action type in getStateNodeTenant: %v", as.Action))
 	}
-	node := r.state.nodes[lock.Scope.GetNodeId()]
-	return node.GetDynamic().Tenant
+	return r.nodesOf(lock.Scope)[0].GetDynamic().GetTenant()
+}
+
+// nodesOf returns the nodes locked by a scope: a single node or, with
+// --host-level, all selected nodes of a host in their restart order.
+func (r *Rolling) nodesOf(scope *Ydb_Maintenance.ActionScope) []*Ydb_Maintenance.Node {
+	if host := scope.GetHost(); host != "" {
+		return r.state.hostNodes[host]
+	}
+	return []*Ydb_Maintenance.Node{r.state.nodes[scope.GetNodeId()]}
+}
+
+// rememberHostNodes groups the nodes by host, keeping the order in which hosts
+// first appear. Storage nodes of a host go first, then tenant nodes by tenant and node id.
+func (r *Rolling) rememberHostNodes(nodes []*Ydb_Maintenance.Node) []string {
+	hosts := []string{}
+	for _, node := range nodes {
+		if _, present := r.state.hostNodes[node.GetHost()]; !present {
+			hosts = append(hosts, node.GetHost())
+		}
+		r.state.hostNodes[node.GetHost()] = append(r.state.hostNodes[node.GetHost()], node)
+	}
+
+	for _, host := range hosts {
+		slices.SortStableFunc(r.state.hostNodes[host], func(a, b *Ydb_Maintenance.Node) int {
+			aStorage, bStorage := a.GetDynamic() == nil, b.GetDynamic() == nil
+			if aStorage != bStorage {
+				if aStorage {
+					return -1
+				}
+				return 1
+			}
+			if c := strings.Compare(a.GetDynamic().GetTenant(), b.GetDynamic().GetTenant()); c != 0 {
+				return c
+			}
+			return int(a.GetNodeId()) - int(b.GetNodeId())
+		})
+	}
+
+	return hosts
+}
+
+// countGroups tells how many action groups the nodes will be locked in.
+func countGroups(nodes []*Ydb_Maintenance.Node, groupKey func(*Ydb_Maintenance.Node) string) int {
+	if groupKey == nil {
+		return len(nodes)
+	}
+
+	groups := make(map[string]bool)
+	for _, node := range nodes {
+		groups[groupKey(node)] = true
+	}
+	return len(groups)
 }
 
 func findLowHigh(minors map[int]bool) (low, high int) {
@@ -677,6 +1003,11 @@ func (r *Rolling) logCompleteResult(result *Ydb_Maintenance.ManageActionResult)
 }
 
 func waitOrCancel(ctx context.Context, delay time.Duration) error {
+	// with no delay, select would pick either case at random
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
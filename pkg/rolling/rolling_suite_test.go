@@ -0,0 +1,13 @@
+package rolling
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRolling(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rolling Suite")
+}
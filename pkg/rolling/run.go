@@ -0,0 +1,60 @@
+package rolling
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/ydb-platform/ydbops/pkg/cmdutil"
+	"github.com/ydb-platform/ydbops/pkg/rolling/restarters"
+)
+
+// RunRestarters restarts storage nodes and then tenant nodes, or all of them
+// host by host with --host-level, honoring --storage and --tenant. Run hooks
+// are run once around the whole restart.
+func RunRestarters(
+	f cmdutil.Factory,
+	opts *RestartOptions,
+	storageRestarter, tenantRestarter restarters.Restarter,
+) error {
+	return RunWithHooks(zap.S(), opts, func() error {
+		return runRestarters(f, opts, storageRestarter, tenantRestarter)
+	})
+}
+
+func runRestarters(
+	f cmdutil.Factory,
+	opts *RestartOptions,
+	storageRestarter, tenantRestarter restarters.Restarter,
+) error {
+	bothUnspecified := !opts.Storage && !opts.Tenant
+
+	if opts.HostLevel {
+		// a single rolling restart locks each host once and restarts all its nodes
+		if !opts.Storage && !bothUnspecified {
+			storageRestarter = nil
+		}
+		if !opts.Tenant && !bothUnspecified {
+			tenantRestarter = nil
+		}
+		hostRestarter := restarters.NewHostRestarter(storageRestarter, tenantRestarter)
+		return NewExecuter(opts, zap.S(), f.GetCMSClient(), f.GetDiscoveryClient(), hostRestarter).Execute()
+	}
+
+	var executer Executer
+	var err error
+	if opts.Storage || bothUnspecified {
+		// TODO(shmel1k@): add logger to NewExecuter parameters
+		executer = NewExecuter(opts, zap.S(), f.GetCMSClient(), f.GetDiscoveryClient(), storageRestarter)
+		err = executer.Execute()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if opts.Tenant || bothUnspecified {
+		executer = NewExecuter(opts, zap.S(), f.GetCMSClient(), f.GetDiscoveryClient(), tenantRestarter)
+		err = executer.Execute()
+	}
+
+	return err
+}
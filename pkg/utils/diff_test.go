@@ -0,0 +1,26 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LineDiff", func() {
+	It("returns nothing for equal texts", func() {
+		Expect(LineDiff("a: 1\nb: 2\n", "a: 1\nb: 2\n")).To(BeEmpty())
+	})
+
+	It("shows changed lines with context around them", func() {
+		before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+		after := "1\n2\n3\n4\n5\nsix\n7\n8\n9\n10\n"
+
+		Expect(LineDiff(before, after)).To(Equal(
+			"...\n 3\n 4\n 5\n-6\n+six\n 7\n 8\n 9\n...\n",
+		))
+	})
+
+	It("shows added and removed lines", func() {
+		Expect(LineDiff("a\nb\n", "a\nc\nb\nd\n")).To(Equal(" a\n+c\n b\n+d\n"))
+		Expect(LineDiff("a\nb\n", "")).To(Equal("-a\n-b\n"))
+	})
+})
@@ -66,6 +66,13 @@ func ActionGroupSorter() cmp.Option {
 	})
 }
 
+// Nodes of one grouped ActionGroup can come in any order as well.
+func ActionSorter() cmp.Option {
+	return protocmp.SortRepeated(func(a, b *Ydb_Maintenance.Action) bool {
+		return a.GetLockAction().GetScope().GetNodeId() < b.GetLockAction().GetScope().GetNodeId()
+	})
+}
+
 // Here is some more black magic.
 //
 // When user specifies what CMS requests are expected, within one CompleteActionRequest
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Auth"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ydb-platform/ydbops/tests/mock"
+)
+
+var _ = Describe("Test Exec", func() {
+	BeforeEach(RunBeforeEach)
+	AfterEach(RunAfterEach)
+
+	DescribeTable("exec", RunTestCase,
+		Entry("runs on selected hosts without creating maintenance tasks", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"exec",
+						"--hosts", "1,3",
+						"--command", "true",
+						// the test hosts do not exist, exec only has to report that
+						"--ssh-args", "ssh -F /dev/null -o BatchMode=yes -o ConnectTimeout=1",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+					},
+					expectedOutputRegexps: []string{
+						`Running on 2 hosts, 10 at a time`,
+						`error: ssh failed`,
+						`HOST +NODE IDS +EXIT CODE`,
+						`ydb-1.ydb.tech +1 +ssh failed`,
+						`ydb-3.ydb.tech +3 +ssh failed`,
+					},
+				},
+			},
+		}),
+	)
+})
@@ -2,6 +2,7 @@ package tests
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -15,6 +16,9 @@ import (
 	"github.com/ydb-platform/ydbops/tests/mock"
 )
 
+// someoneUser is the owner to list tasks of, the mock CMS does not track owners
+var someoneUser = "someone@builtin"
+
 var _ = Describe("Test Maintenance", func() {
 	BeforeEach(RunBeforeEach)
 	AfterEach(RunAfterEach)
@@ -79,6 +83,7 @@ var _ = Describe("Test Maintenance", func() {
 						&Ydb_Maintenance.GetMaintenanceTaskRequest{
 							TaskUid: "task-uuid-1",
 						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
 					},
 					expectedOutputRegexps: []string{
 						fmt.Sprintf("Uid: %s%s\n", cms.TaskUuidPrefix, uuidRegexpString),
@@ -267,6 +272,7 @@ var _ = Describe("Test Maintenance", func() {
 						&Ydb_Maintenance.GetMaintenanceTaskRequest{
 							TaskUid: "task-uuid-1",
 						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
 					},
 					expectedOutputRegexps: []string{
 						fmt.Sprintf("Uid: %s%s\n", cms.TaskUuidPrefix, uuidRegexpString),
@@ -396,5 +402,653 @@ var _ = Describe("Test Maintenance", func() {
 			},
 		},
 		),
+		Entry("maintenance commands print structured output with --output", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"--output", "json",
+						"maintenance",
+						"create",
+						"--duration", "180",
+						"--availability-mode", "strong",
+						"--hosts=1,2",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-uuid-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(time.Second * 180, 1, 2),
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf(`^\{\n  "uid": "%s%s",\n`, cms.TaskUuidPrefix, uuidRegexpString),
+						`"actionGroups": \[`,
+						fmt.Sprintf(`"taskUid": "%s%s"`, cms.TaskUuidPrefix, uuidRegexpString),
+						`"nodeId": 1,\n\s*"status": "PERFORMED"`,
+						`"deadline": "`,
+						`"nodeId": 2,\n\s*"status": "PENDING"`,
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"--output", "yaml",
+						"maintenance",
+						"complete",
+						"--task-id",
+						testWillInsertTaskUuid,
+						"--hosts=1",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						`^actionStatuses:\n- actionUid:\n`,
+						fmt.Sprintf(`    taskUid: %s%s\n`, cms.TaskUuidPrefix, uuidRegexpString),
+						`  status: SUCCESS\n`,
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"--output", "json",
+						"maintenance",
+						"list",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf(`^\[\n  \{\n    "uid": "%s%s",\n`, cms.TaskUuidPrefix, uuidRegexpString),
+						`"nodeId": 2,\n\s*"status": "PENDING"`,
+					},
+				},
+			},
+		},
+		),
+		Entry("wait for maintenance actions to become PERFORMED", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"create",
+						"--duration", "180",
+						"--availability-mode", "strong",
+						"--hosts=1,2",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-uuid-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(time.Second * 180, 1, 2),
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Your task id is:\n\n%s%s\n\n", cms.TaskUuidPrefix, uuidRegexpString),
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"wait",
+						"--task-id",
+						testWillInsertTaskUuid,
+						"--hosts=1",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Lock on node 1 PERFORMED",
+						"All 1 awaited actions of task .* are PERFORMED",
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"wait",
+						"--task-id",
+						testWillInsertTaskUuid,
+						"--hosts=2",
+						"--timeout", "1",
+						"--cms-query-interval", "3",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Lock on node 2 ACTION_STATUS_PENDING",
+						"timed out after 1s waiting for task .*: 1 of 1 actions are not PERFORMED yet",
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"complete",
+						"--task-id",
+						testWillInsertTaskUuid,
+						"--hosts=1",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("  Completed action id: %s, status: SUCCESS", uuidRegexpString),
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"wait",
+						"--task-id",
+						testWillInsertTaskUuid,
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Lock on node 2 PERFORMED",
+						"All 1 awaited actions of task .* are PERFORMED",
+					},
+				},
+			},
+		},
+		),
+		Entry("hold extends locks of a maintenance task and drops it on signal", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			additionalTestBehaviour: &mock.AdditionalTestBehaviour{
+				SignalDelayMs: 1500,
+			},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"create",
+						"--duration", "180",
+						"--availability-mode", "strong",
+						"--hosts=1",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-uuid-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(time.Second * 180, 1),
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Your task id is:\n\n%s%s\n\n", cms.TaskUuidPrefix, uuidRegexpString),
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"hold",
+						"--task-id",
+						testWillInsertTaskUuid,
+						"--duration", "3600",
+						"--renew-before", "600",
+						"--cms-query-interval", "60",
+						"--on-exit", "drop",
+						"--task-id-file", filepath.Join(os.TempDir(), "ydbops-hold-task-id"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-uuid-2",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(time.Second * 3600, 1),
+						},
+						// the locks are passed on to the replacement only once the original task is dropped
+						&Ydb_Maintenance.DropMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-uuid-2",
+						},
+						&Ydb_Maintenance.DropMaintenanceTaskRequest{
+							TaskUid: "task-uuid-2",
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Holding locks of maintenance task",
+						"Requested lock extension for task",
+						"Locks extended: task .* replaced",
+						fmt.Sprintf("Your task id is now:\n\n%s%s\n\n", cms.TaskUuidPrefix, uuidRegexpString),
+						"Interrupted, dropping maintenance task",
+					},
+				},
+			},
+		},
+		),
+		Entry("list tasks of all users filtered by host and status", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"create",
+						"--duration", "180",
+						"--availability-mode", "strong",
+						"--hosts=1,2",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-uuid-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(time.Second * 180, 1, 2),
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Your task id is:\n\n%s%s\n\n", cms.TaskUuidPrefix, uuidRegexpString),
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"list",
+						"--all-users",
+						"--hosts=ydb-2.ydb.tech",
+						"--status=pending",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Uid: %s%s\n", cms.TaskUuidPrefix, uuidRegexpString),
+						"Description: Rolling restart maintenance task\n",
+						"  Lock on node 2 ACTION_STATUS_PENDING, \\S+ \\[host ydb-2.ydb.tech; dc DC-1\\]\n",
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"list",
+						"--all-users",
+						"--hosts=2",
+						"--status=performed",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+					},
+					expectedOutputRegexps: []string{
+						"There are no maintenance tasks matching the filters at the moment.",
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"list",
+						"--user", "someone@builtin",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &someoneUser,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-uuid-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Uid: %s%s\n", cms.TaskUuidPrefix, uuidRegexpString),
+					},
+				},
+			},
+		},
+		),
+		Entry("complete all performed actions and drop all own tasks", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"create",
+						"--duration", "180",
+						"--availability-mode", "strong",
+						"--hosts=1,2",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-uuid-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(time.Second * 180, 1, 2),
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Your task id is:\n\n%s%s\n\n", cms.TaskUuidPrefix, uuidRegexpString),
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"complete",
+						"--task-id",
+						testWillInsertTaskUuid,
+						"--all",
+						"--yes",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Skipping action that is not PERFORMED: Lock on node 2 ACTION_STATUS_PENDING",
+						fmt.Sprintf("  Completed action id: %s, status: SUCCESS", uuidRegexpString),
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"drop",
+						"--all-mine",
+						"--prefix", cms.TaskUuidPrefix,
+						"--yes",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.DropMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Dropped maintenance task %s%s\n", cms.TaskUuidPrefix, uuidRegexpString),
+					},
+				},
+			},
+		},
+		),
+		Entry("lock and complete single pdisks", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"create",
+						"--duration", "180",
+						"--availability-mode", "strong",
+						"--pdisks=1:1000,2:1001",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-uuid-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromPDiskIdsFixedDuration(
+								time.Second*180, [2]uint32{1, 1000}, [2]uint32{2, 1001},
+							),
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Your task id is:\n\n%s%s\n\n", cms.TaskUuidPrefix, uuidRegexpString),
+						"  Lock on pdisk 1:1000 PERFORMED",
+						"  Lock on pdisk 2:1001 ACTION_STATUS_PENDING",
+					},
+				},
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"complete",
+						"--task-id",
+						testWillInsertTaskUuid,
+						"--pdisks=1:1000",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("  Completed action id: %s, status: SUCCESS", uuidRegexpString),
+					},
+				},
+			},
+		},
+		),
 	)
 })
@@ -18,24 +18,30 @@ func deleteFromSlice[T any](s []T, i int) []T {
 }
 
 func (s *YdbMock) setPendingOrPerformed(
+	taskUID string,
 	currentNodeID uint32,
 	availabilityMode AvailabilityMode,
 ) ActionState_ActionStatus {
-	result, err := s.setPendingOrPerformedDynNodes(currentNodeID)
+	// a node is locked by one task at a time, like in the real CMS
+	if holder := s.lockHolders[currentNodeID]; holder != "" {
+		if holder == taskUID {
+			return ActionState_ACTION_STATUS_PERFORMED
+		}
+		return ActionState_ACTION_STATUS_PENDING
+	}
+
+	result, err := s.setPendingOrPerformedDynNodes(taskUID, currentNodeID)
 	if err == nil {
 		return result
 	}
 
-	return s.setPendingOrPerformedStorageNodes(currentNodeID, availabilityMode)
+	return s.setPendingOrPerformedStorageNodes(taskUID, currentNodeID, availabilityMode)
 }
 
 func (s *YdbMock) setPendingOrPerformedDynNodes(
+	taskUID string,
 	currentNodeID uint32,
 ) (ActionState_ActionStatus, error) {
-	if s.isNodeCurrentlyReleased[currentNodeID] {
-		return ActionState_ACTION_STATUS_PERFORMED, nil
-	}
-
 	// for dynamic nodes: for convenience, we just configure the
 	// CMS mock to release UP TO N nodes per tenant.
 	for _, n := range s.nodes {
@@ -49,12 +55,12 @@ func (s *YdbMock) setPendingOrPerformedDynNodes(
 		for _, other := range s.nodes {
 			if other.GetDynamic() != nil &&
 				other.GetDynamic().GetTenant() == tenant &&
-				s.isNodeCurrentlyReleased[other.NodeId] {
+				s.lockHolders[other.NodeId] != "" {
 				releasedInTenant++
 			}
 		}
 		if releasedInTenant < s.additionalTestBehaviour.MaxDynnodesPerformedPerTenant {
-			s.isNodeCurrentlyReleased[currentNodeID] = true
+			s.lockHolders[currentNodeID] = taskUID
 			return ActionState_ACTION_STATUS_PERFORMED, nil
 		}
 		return ActionState_ACTION_STATUS_PENDING, nil
@@ -64,6 +70,7 @@ func (s *YdbMock) setPendingOrPerformedDynNodes(
 }
 
 func (s *YdbMock) setPendingOrPerformedStorageNodes(
+	taskUID string,
 	currentNodeID uint32,
 	availabilityMode AvailabilityMode,
 ) ActionState_ActionStatus {
@@ -71,7 +78,7 @@ func (s *YdbMock) setPendingOrPerformedStorageNodes(
 	for _, nodeGroup := range s.nodeGroups {
 		alreadyReleased := 0
 		for _, nodeID := range nodeGroup {
-			if s.isNodeCurrentlyReleased[nodeID] {
+			if s.lockHolders[nodeID] != "" {
 				alreadyReleased++
 			}
 		}
@@ -81,24 +88,20 @@ func (s *YdbMock) setPendingOrPerformedStorageNodes(
 				continue
 			}
 
-			if s.isNodeCurrentlyReleased[currentNodeID] {
-				return ActionState_ACTION_STATUS_PERFORMED
-			}
-
 			if alreadyReleased == 0 {
-				s.isNodeCurrentlyReleased[currentNodeID] = true
+				s.lockHolders[currentNodeID] = taskUID
 				return ActionState_ACTION_STATUS_PERFORMED
 			}
 
 			if alreadyReleased == 1 &&
 				(availabilityMode == AvailabilityMode_AVAILABILITY_MODE_WEAK ||
 					availabilityMode == AvailabilityMode_AVAILABILITY_MODE_FORCE) {
-				s.isNodeCurrentlyReleased[currentNodeID] = true
+				s.lockHolders[currentNodeID] = taskUID
 				return ActionState_ACTION_STATUS_PERFORMED
 			}
 
 			if availabilityMode == AvailabilityMode_AVAILABILITY_MODE_FORCE {
-				s.isNodeCurrentlyReleased[currentNodeID] = true
+				s.lockHolders[currentNodeID] = taskUID
 				return ActionState_ACTION_STATUS_PERFORMED
 			}
 		}
@@ -107,6 +110,14 @@ func (s *YdbMock) setPendingOrPerformedStorageNodes(
 	return ActionState_ACTION_STATUS_PENDING
 }
 
+// releaseLock unlocks the node if the task holds its lock. Locks of
+// other tasks on the same node are kept.
+func (s *YdbMock) releaseLock(taskUID string, nodeID uint32) {
+	if s.lockHolders[nodeID] == taskUID {
+		s.lockHolders[nodeID] = ""
+	}
+}
+
 func whichStorageNodeIs(host string) uint32 {
 	// fake host fqdns look like this: ydb-%d.ydb.tech
 	parts := strings.Split(host, "-")
@@ -120,6 +131,14 @@ func whichStorageNodeIs(host string) uint32 {
 }
 
 func nodeIdFromAction(action *Action) uint32 {
+	// for simplicity, locking a pdisk locks the whole node it belongs to
+	if pdisk := action.GetLockAction().Scope.GetPdisk(); pdisk != nil {
+		if location := pdisk.GetPdiskLocation(); location != nil {
+			return whichStorageNodeIs(location.GetHost())
+		}
+		return pdisk.GetPdiskId().GetNodeId()
+	}
+
 	nodeId := action.GetLockAction().Scope.GetNodeId()
 	if nodeId == 0 { // Scope is Host
 		host := action.GetLockAction().Scope.GetHost()
@@ -132,17 +151,25 @@ func nodeIdFromAction(action *Action) uint32 {
 func (s *YdbMock) givePerformedOrPendingStatus(taskOptions *MaintenanceTaskOptions, action *Action) *ActionState {
 	currentNodeID := nodeIdFromAction(action)
 
-	status := s.setPendingOrPerformed(currentNodeID, taskOptions.AvailabilityMode)
+	status := s.setPendingOrPerformed(taskOptions.TaskUid, currentNodeID, taskOptions.AvailabilityMode)
 
 	return &ActionState{
 		Action:    action,
 		Status:    status,
 		Reason:    ActionState_ACTION_REASON_UNSPECIFIED,
-		Deadline:  timestamppb.New(time.Now().Add(time.Minute * 3)),
+		Deadline:  timestamppb.New(time.Now().Add(lockDuration(action))),
 		ActionUid: s.actionToActionUID[action],
 	}
 }
 
+// lockDuration is how long CMS keeps the lock, 3 minutes if the duration is not specified.
+func lockDuration(action *Action) time.Duration {
+	if duration := action.GetLockAction().GetDuration(); duration != nil {
+		return duration.AsDuration()
+	}
+	return time.Minute * 3
+}
+
 func (s *YdbMock) makeGroupStatesFor(taskOptions *MaintenanceTaskOptions, actionGroups []*ActionGroup) []*ActionGroupStates {
 	result := make([]*ActionGroupStates, 0, len(actionGroups))
 	for _, ag := range actionGroups {
@@ -196,7 +223,7 @@ func (s *YdbMock) cleanupActionByID(actionID string) {
 
 				nodeId := nodeIdFromAction(action)
 
-				s.isNodeCurrentlyReleased[nodeId] = false
+				s.releaseLock(task.options.TaskUid, nodeId)
 				delete(s.actionToActionUID, action)
 				s.cleanupActionGroupState(task, actionID)
 				actionGroup.Actions = deleteFromSlice(actionGroup.Actions, i)
@@ -221,7 +248,7 @@ func (s *YdbMock) refreshStatesForTask(taskUID string) {
 	for _, ags := range task.actionGroupStates {
 		for _, as := range ags.ActionStates {
 			nodeID := nodeIdFromAction(as.Action)
-			as.Status = s.setPendingOrPerformed(nodeID, task.options.AvailabilityMode)
+			as.Status = s.setPendingOrPerformed(taskUID, nodeID, task.options.AvailabilityMode)
 		}
 	}
 }
@@ -72,6 +72,39 @@ func MakeActionGroupsFromNodesIdsFixedDuration(duration time.Duration, nodeIDs .
 	return result
 }
 
+// MakeActionGroupsFromPDiskIdsFixedDuration takes pairs of node id and pdisk id.
+func MakeActionGroupsFromPDiskIdsFixedDuration(duration time.Duration, pdisks ...[2]uint32) []*Ydb_Maintenance.ActionGroup {
+	result := make([]*Ydb_Maintenance.ActionGroup, 0, len(pdisks))
+	for _, pdisk := range pdisks {
+		result = append(result,
+			&Ydb_Maintenance.ActionGroup{
+				Actions: []*Ydb_Maintenance.Action{
+					{
+						Action: &Ydb_Maintenance.Action_LockAction{
+							LockAction: &Ydb_Maintenance.LockAction{
+								Scope: &Ydb_Maintenance.ActionScope{
+									Scope: &Ydb_Maintenance.ActionScope_Pdisk{
+										Pdisk: &Ydb_Maintenance.ActionScope_PDisk{
+											Pdisk: &Ydb_Maintenance.ActionScope_PDisk_PdiskId{
+												PdiskId: &Ydb_Maintenance.ActionScope_PDiskId{
+													NodeId:  pdisk[0],
+													PdiskId: pdisk[1],
+												},
+											},
+										},
+									},
+								},
+								Duration: durationpb.New(duration),
+							},
+						},
+					},
+				},
+			},
+		)
+	}
+	return result
+}
+
 func MakeActionGroupsFromNodeIds(nodeIDs ...uint32) []*Ydb_Maintenance.ActionGroup {
 	return MakeActionGroupsFromNodeIdsWithInflight(1, nodeIDs...)
 }
@@ -101,6 +134,31 @@ func MakeActionGroupsFromNodeIdsWithInflight(nodesInflight int, nodeIDs ...uint3
 	return result
 }
 
+// MakeGroupedActionGroupsFromNodeIds puts each group of nodes into one action group.
+func MakeGroupedActionGroupsFromNodeIds(groups ...[]uint32) []*Ydb_Maintenance.ActionGroup {
+	duration := durationpb.New(determineRestartDuration(len(groups), 1))
+	result := make([]*Ydb_Maintenance.ActionGroup, 0, len(groups))
+	for _, group := range groups {
+		ag := &Ydb_Maintenance.ActionGroup{}
+		for _, nodeID := range group {
+			ag.Actions = append(ag.Actions, &Ydb_Maintenance.Action{
+				Action: &Ydb_Maintenance.Action_LockAction{
+					LockAction: &Ydb_Maintenance.LockAction{
+						Scope: &Ydb_Maintenance.ActionScope{
+							Scope: &Ydb_Maintenance.ActionScope_NodeId{
+								NodeId: nodeID,
+							},
+						},
+						Duration: duration,
+					},
+				},
+			})
+		}
+		result = append(result, ag)
+	}
+	return result
+}
+
 func MakeActionGroupsFromHostFQDNsFixedDuration(duration time.Duration, hostFQDNs ...string) []*Ydb_Maintenance.ActionGroup {
 	result := make([]*Ydb_Maintenance.ActionGroup, 0, len(hostFQDNs))
 	for _, hostFQDN := range hostFQDNs {
@@ -158,6 +216,9 @@ type TestNodeInfo struct {
 	Version    string
 	Datacenter string
 	State      Ydb_Maintenance.ItemState
+	LoadFactor float32
+	// Host puts the node on another node's host, e.g. a dynnode next to a storage node
+	Host string
 }
 
 func CreateNodesFromShortConfig(nodeGroups [][]uint32, nodeInfo map[uint32]TestNodeInfo) []*Ydb_Maintenance.Node {
@@ -201,6 +262,10 @@ func CreateNodesFromShortConfig(nodeGroups [][]uint32, nodeInfo map[uint32]TestN
 				node.State = testNodeInfo.State
 			}
 
+			if len(testNodeInfo.Host) > 0 {
+				node.Host = testNodeInfo.Host
+			}
+
 			if len(testNodeInfo.Datacenter) > 0 {
 				datacenter := &testNodeInfo.Datacenter
 				node.Location.DataCenter = datacenter
@@ -213,14 +278,19 @@ func CreateNodesFromShortConfig(nodeGroups [][]uint32, nodeInfo map[uint32]TestN
 }
 
 func (s *YdbMock) SetNodeConfiguration(nodeGroups [][]uint32, nodeInfo map[uint32]TestNodeInfo) {
-	s.isNodeCurrentlyReleased = make(map[uint32]bool)
+	s.lockHolders = make(map[uint32]string)
 	s.nodeGroups = nodeGroups
 
 	for _, group := range s.nodeGroups {
 		for _, nodeID := range group {
-			s.isNodeCurrentlyReleased[nodeID] = false
+			s.lockHolders[nodeID] = ""
 		}
 	}
 
 	s.nodes = CreateNodesFromShortConfig(nodeGroups, nodeInfo)
+
+	s.loadFactors = make(map[uint32]float32)
+	for nodeID, info := range nodeInfo {
+		s.loadFactors[nodeID] = info.LoadFactor
+	}
 }
@@ -0,0 +1,3 @@
+#!/bin/bash
+
+echo "hook node=${YDB_NODE_ID} restart=${YDB_RESTART_RESULT} run=${YDB_RUN_RESULT}"
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/ydb-platform/ydb-go-genproto/Ydb_Auth_V1"
@@ -66,6 +67,8 @@ type YdbMock struct {
 	// block-4-2 erasure type and contains 8 storage nodes, then it has
 	// only one group: {1, 2, 3, 4, 5, 6, 7, 8}.
 	nodeGroups [][]uint32
+	// Load factors reported for dynamic nodes by ListEndpoints.
+	loadFactors map[uint32]float32
 
 	// This is just a log of all requests that rolling-restart has sent to
 	// the CMS. It is populated during the test and then used only once to
@@ -77,8 +80,9 @@ type YdbMock struct {
 	tasks map[string]*fakeMaintenanceTask
 	// These two fields are just 'indexes', they can be calculated from `tasks`
 	// but are used for convenience in CMS logic.
-	isNodeCurrentlyReleased map[uint32]bool
-	actionToActionUID       map[*Action]*ActionUid
+	// lockHolders are the tasks holding the locks of the nodes, empty for nodes not locked
+	lockHolders       map[uint32]string
+	actionToActionUID map[*Action]*ActionUid
 }
 
 func makeSuccessfulOperation() *Ydb_Operations.Operation {
@@ -154,8 +158,7 @@ func (s *YdbMock) DropMaintenanceTask(ctx context.Context, req *DropMaintenanceT
 	for _, ag := range s.tasks[req.TaskUid].actionGroups {
 		for _, action := range ag.Actions {
 			delete(s.actionToActionUID, action)
-			actionNodeId := action.GetLockAction().Scope.GetNodeId()
-			s.isNodeCurrentlyReleased[actionNodeId] = false
+			s.releaseLock(req.TaskUid, nodeIdFromAction(action))
 		}
 	}
 	delete(s.tasks, req.TaskUid)
@@ -171,6 +174,8 @@ func (s *YdbMock) ListMaintenanceTasks(ctx context.Context, req *ListMaintenance
 	for task := range s.tasks {
 		taskUids = append(taskUids, task)
 	}
+	// sorted for tests to expect requests about several tasks in a fixed order
+	sort.Strings(taskUids)
 	result := &ListMaintenanceTasksResult{
 		TasksUids: taskUids,
 	}
@@ -250,6 +255,23 @@ func (s *YdbMock) WhoAmI(ctx context.Context, req *Ydb_Discovery.WhoAmIRequest)
 	return &Ydb_Discovery.WhoAmIResponse{Operation: wrapIntoOperation(result)}, nil
 }
 
+func (s *YdbMock) ListEndpoints(ctx context.Context, req *Ydb_Discovery.ListEndpointsRequest) (*Ydb_Discovery.ListEndpointsResponse, error) {
+	s.RequestLog = append(s.RequestLog, req)
+	result := &Ydb_Discovery.ListEndpointsResult{}
+	for _, node := range s.nodes {
+		if node.GetDynamic().GetTenant() != req.Database {
+			continue
+		}
+		result.Endpoints = append(result.Endpoints, &Ydb_Discovery.EndpointInfo{
+			Address:    node.Host,
+			Port:       node.Port,
+			NodeId:     node.NodeId,
+			LoadFactor: s.loadFactors[node.NodeId],
+		})
+	}
+	return &Ydb_Discovery.ListEndpointsResponse{Operation: wrapIntoOperation(result)}, nil
+}
+
 func (s *YdbMock) Login(ctx context.Context, req *Ydb_Auth.LoginRequest) (*Ydb_Auth.LoginResponse, error) {
 	s.RequestLog = append(s.RequestLog, req)
 	if req.Password == TestPassword && req.User == TestUser {
@@ -281,11 +303,11 @@ func (s *YdbMock) ListDatabases(ctx context.Context, req *Ydb_Cms.ListDatabasesR
 
 func NewYdbMockServer() *YdbMock {
 	server := &YdbMock{
-		tasks:                   make(map[string]*fakeMaintenanceTask),
-		actionToActionUID:       make(map[*Action]*ActionUid),
-		nodes:                   nil, // cluster node configuration filled by the test itself
-		nodeGroups:              nil, // cluster node configuration filled by the test itself
-		isNodeCurrentlyReleased: nil, // cluster node configuration filled by the test itself
+		tasks:             make(map[string]*fakeMaintenanceTask),
+		actionToActionUID: make(map[*Action]*ActionUid),
+		nodes:             nil, // cluster node configuration filled by the test itself
+		nodeGroups:        nil, // cluster node configuration filled by the test itself
+		lockHolders:       nil, // cluster node configuration filled by the test itself
 	}
 
 	return server
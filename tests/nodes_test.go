@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/ydb-platform/ydb-go-genproto/draft/protos/Ydb_Maintenance"
+	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Auth"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ydb-platform/ydbops/tests/mock"
+)
+
+var _ = Describe("Test Nodes", func() {
+	BeforeEach(RunBeforeEach)
+	AfterEach(RunAfterEach)
+
+	DescribeTable("nodes", RunTestCase,
+		Entry("list storage and tenant nodes with summary", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2},
+				{3, 4},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{
+				3: {
+					IsDynnode:  true,
+					TenantName: "/Root/db1",
+					Version:    "24.1.1",
+				},
+				4: {
+					IsDynnode:  true,
+					TenantName: "/Root/db1",
+					Version:    "24.1.1",
+					State:      Ydb_Maintenance.ItemState_ITEM_STATE_MAINTENANCE,
+				},
+			},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"nodes",
+						"list",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+					},
+					expectedOutputRegexps: []string{
+						`NODE ID +HOST +DC +RACK +TYPE +TENANT +STATE +VERSION +UPTIME`,
+						`1 +ydb-1.ydb.tech .* storage +- +UP`,
+						`4 +ydb-4.ydb.tech .* dynamic +/Root/db1 +MAINTENANCE +24.1.1`,
+						`Total nodes: 4`,
+						`By version:\n  24.1.1: 2\n`,
+						`By tenant:\n  /Root/db1: 2\n  <storage>: 2\n`,
+					},
+				},
+			},
+		}),
+		Entry("list only tenant nodes as csv", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2},
+				{3},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{
+				3: {
+					IsDynnode:  true,
+					TenantName: "/Root/db1",
+					Version:    "24.1.1",
+				},
+			},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"nodes",
+						"list",
+						"--tenant",
+						"--format", "csv",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+					},
+					expectedOutputRegexps: []string{
+						`nodeId,host,port,dataCenter,rack,type,tenant,state,version,uptimeSeconds\n`,
+						`3,ydb-3.ydb.tech,[0-9]+,[^,]*,[^,]*,dynamic,/Root/db1,UP,24.1.1,[0-9]+\n$`,
+					},
+				},
+			},
+		}),
+	)
+})
@@ -174,5 +174,56 @@ var _ = Describe("Test Profile", func() {
 			},
 		},
 		),
+		Entry("profile extends another profile and reads endpoint from a file", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--profile-file",
+						filepath.Join(".", "test-data", "config_with_extends.yaml"),
+						"--availability-mode", "strong",
+						"--cms-query-interval", "1",
+						"run",
+						"--hosts=1",
+						"--storage",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(1),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{},
+				},
+			},
+		},
+		),
 	)
 })
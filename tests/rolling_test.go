@@ -2,6 +2,7 @@ package tests
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/ydb-platform/ydb-go-genproto/protos/Ydb_Discovery"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/ydb-platform/ydbops/pkg/client/cms"
 	"github.com/ydb-platform/ydbops/tests/mock"
 )
 
@@ -19,6 +21,7 @@ var _ = Describe("Test Rolling", func() {
 	now := time.Now()
 	twoNodesStartedEarlier := now.Add(-10 * time.Minute)
 	startedFilterValue := now.Add(-5 * time.Minute)
+	planFile := filepath.Join(os.TempDir(), "ydbops-e2e-plan.yaml")
 
 	BeforeEach(RunBeforeEach)
 	AfterEach(RunAfterEach)
@@ -1318,5 +1321,997 @@ var _ = Describe("Test Rolling", func() {
 			},
 		},
 		),
+		Entry("restart custom node groups atomically with --group-by custom", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2},
+				{3, 4},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--hosts=1,2,3,4",
+						"--group-by", "custom",
+						"--groups-file", filepath.Join(".", "test-data", "groups.yaml"),
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--storage",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeGroupedActionGroupsFromNodeIds([]uint32{1, 3}, []uint32{2, 4}),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-2",
+								},
+							},
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-2",
+									ActionId: "action-UUID-3",
+								},
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-2",
+									ActionId: "action-UUID-4",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		),
+		Entry("--order dc restarts datacenters one by one, in separate maintenance tasks", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{
+				2: {
+					Datacenter: "DC-2",
+				},
+			},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--hosts=1,2,3",
+						"--order", "dc",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--storage",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(1, 3),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-2",
+									ActionId: "action-UUID-2",
+								},
+							},
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-2",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(2),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-2",
+									GroupId:  "group-UUID-3",
+									ActionId: "action-UUID-3",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Restarting datacenter DC-1 \\(2 nodes\\), 1 out of 2",
+						"Restarting datacenter DC-2 \\(1 nodes\\), 2 out of 2",
+					},
+				},
+			},
+		},
+		),
+		Entry("--order oldest restarts nodes with the earliest start time first", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{
+				1: {
+					StartTime: now.Add(-20 * time.Minute),
+				},
+				2: {
+					StartTime: now.Add(-10 * time.Minute),
+				},
+				3: {
+					StartTime: now.Add(-30 * time.Minute),
+				},
+			},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--hosts=1,2,3",
+						"--order", "oldest",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--storage",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(1, 2, 3),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-2",
+									ActionId: "action-UUID-2",
+								},
+							},
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-3",
+									ActionId: "action-UUID-3",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Restart node with id: 3\\n",
+						"Restart node with id: 1\\n",
+						"Restart node with id: 2\\n",
+					},
+				},
+			},
+		},
+		),
+		Entry("--order least-loaded restarts tenant nodes with the lowest load factor first", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+				{9, 10, 11},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{
+				9: {
+					IsDynnode:  true,
+					TenantName: "fakeTenant1",
+					LoadFactor: 0.9,
+				},
+				10: {
+					IsDynnode:  true,
+					TenantName: "fakeTenant1",
+					LoadFactor: 0.1,
+				},
+				11: {
+					IsDynnode:  true,
+					TenantName: "fakeTenant1",
+					LoadFactor: 0.5,
+				},
+			},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--order", "least-loaded",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--tenant",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Discovery.ListEndpointsRequest{
+							Database: "fakeTenant1",
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(9, 10, 11),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-2",
+									ActionId: "action-UUID-2",
+								},
+							},
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-3",
+									ActionId: "action-UUID-3",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Restart node with id: 10\\n",
+						"Restart node with id: 11\\n",
+						"Restart node with id: 9\\n",
+					},
+				},
+			},
+		},
+		),
+		Entry("--host-level restarts storage and tenant nodes of a host under a single host lock", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+				{9, 10},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{
+				9: {
+					IsDynnode:  true,
+					TenantName: "fakeTenant2",
+					Host:       "ydb-1.ydb.tech",
+				},
+				10: {
+					IsDynnode:  true,
+					TenantName: "fakeTenant1",
+					Host:       "ydb-1.ydb.tech",
+				},
+			},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--host-level",
+						"--hosts", "ydb-1.ydb.tech",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							// three nodes of the host are restarted one after another
+							ActionGroups: mock.MakeActionGroupsFromHostFQDNsFixedDuration(3*181*time.Second, "ydb-1.ydb.tech"),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Restart node with id: 1\\n",
+						"Restart node with id: 10\\n",
+						"Restart node with id: 9\\n",
+						"Total node progress: 3 out of 3",
+					},
+				},
+			},
+		},
+		),
+		Entry("node and run hooks are run around restarts", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--storage",
+						"--hosts", "1",
+						"--pre-run-hook", filepath.Join(".", "mock", "echo-hook.sh"),
+						"--pre-node-hook", filepath.Join(".", "mock", "echo-hook.sh"),
+						"--post-node-hook", filepath.Join(".", "mock", "echo-hook.sh"),
+						"--post-run-hook", filepath.Join(".", "mock", "echo-hook.sh"),
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(1),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"hook node= restart= run=\n",
+						"hook node=1 restart= run=\n",
+						"Restart node with id: 1\n",
+						"hook node=1 restart=ok run=\n",
+						"hook node= restart= run=ok\n",
+					},
+				},
+			},
+		},
+		),
+		Entry("a node is skipped when its pre-node hook fails with --pre-node-hook-failure skip", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--storage",
+						"--hosts", "1",
+						"--pre-node-hook", "/bin/false",
+						"--pre-node-hook-failure", "skip",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(1),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Pre-node hook failed for node 1, skipping the node",
+						"Total node progress: 0 out of 1, 1 skipped",
+					},
+				},
+			},
+		},
+		),
+		Entry("the task is dropped when --max-failed-nodes nodes have failed", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--storage",
+						"--hosts", "1,2",
+						"--restart-retry-number", "1",
+						"--max-failed-nodes", "1",
+						"--payload", "/bin/false",
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							// a single attempt per node
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(122*time.Second, 1, 2),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.DropMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+					},
+					expectedOutputRegexps: []string{
+						"1 nodes have failed to restart, --max-failed-nodes is 1, stopping the restart",
+						"Cleaning up maintenance tasks of the stopped restart",
+					},
+				},
+			},
+		},
+		),
+		Entry("cleanup keeps manual tasks and drops stale rolling restart tasks only with --drop-stale-rolling-tasks", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3},
+				{4},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{
+				1: {
+					IsDynnode: false,
+					Version:   "23.4.1",
+				},
+				2: {
+					IsDynnode: false,
+					Version:   "23.4.1",
+				},
+				3: {
+					IsDynnode: false,
+					Version:   "23.4.1",
+				},
+				4: {
+					IsDynnode:  true,
+					TenantName: "fakeTenant",
+					Version:    "23.3.1",
+				},
+			},
+			additionalTestBehaviour: &mock.AdditionalTestBehaviour{
+				RestartNodesOnNewVersion: "24.1.1",
+			},
+			steps: []StepData{
+				{
+					ydbopsInvocation: Command{
+						"--endpoint", "grpcs://localhost:2135",
+						"--user", mock.TestUser,
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+						"maintenance",
+						"create",
+						"--duration", "180",
+						"--availability-mode", "strong",
+						"--hosts=4",
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-uuid-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(time.Second*180, 4),
+						},
+					},
+					expectedOutputRegexps: []string{
+						fmt.Sprintf("Your task id is:\n\n%s%s\n\n", cms.TaskUuidPrefix, uuidRegexpString),
+					},
+				},
+				{
+					// the compatibility check aborts the restart and leaves its task behind
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--hosts", "1,2",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-2",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(1, 2),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-2",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-2",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+					},
+					expectedOutputRegexps: []string{
+						"Keeping maintenance task maintenance-.*, it was not created by a rolling restart",
+						".*Triggered this check: 24 major is incompatible with 23-3.*",
+					},
+				},
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--hosts", "3",
+						"--drop-stale-rolling-tasks",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-2",
+						},
+						&Ydb_Maintenance.DropMaintenanceTaskRequest{
+							TaskUid: "task-UUID-2",
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-3",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIds(3),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-3",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+						// tenant nodes are restarted separately, nothing is dropped this time
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.GetMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Will drop 1 maintenance tasks: rolling-restart-",
+					},
+				},
+			},
+		},
+		),
+		Entry("locks expiring before the restart are requested again in a follow-up task", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "force",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--storage",
+						"--hosts", "1,2",
+						"--duration", "1",
+						"--restart-retry-number", "1",
+						"--delay-between-restarts", "0s",
+						"--extend-locks",
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_FORCE,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(2*time.Second, 1, 2),
+						},
+						// the locks last as long as both restarts are expected to take, so by the time
+						// the nodes are dispatched node 2 is expected to be restarted after its lock expires
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-2",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_FORCE,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodesIdsFixedDuration(time.Second, 2),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+						&Ydb_Maintenance.DropMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						// CMS grants node 2 to the follow-up task only once the first task is dropped
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-2",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-2",
+									GroupId:  "group-UUID-2",
+									ActionId: "action-UUID-2",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"The restart is taking longer than the 2s its locks were requested for",
+						"Lock of node 2 expires at .*, before its restart is expected to finish at",
+						"Maintenance task rolling-restart-.* continues the restart of task rolling-restart-",
+					},
+				},
+			},
+		},
+		),
+		Entry("a restart plan is written with --plan-out and executed as is with --plan", TestCase{
+			nodeConfiguration: [][]uint32{
+				{1, 2, 3},
+				{4},
+			},
+			nodeInfoMap: map[uint32]mock.TestNodeInfo{},
+			steps: []StepData{
+				{
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--storage",
+						"--hosts", "1,2",
+						"--nodes-inflight", "2",
+						"--plan-out", planFile,
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+					},
+					expectedOutputRegexps: []string{
+						"Planned 2 nodes to restart with RunRestarter in 1 waves, the plan is written to .*ydbops-e2e-plan.yaml",
+					},
+				},
+				{
+					// the plan restarts storage nodes 1 and 2 with --nodes-inflight 2, whatever the flags say
+					ydbopsInvocation: []string{
+						"--endpoint", "grpcs://localhost:2135",
+						"--verbose",
+						"--availability-mode", "strong",
+						"--user", mock.TestUser,
+						"--cms-query-interval", "1",
+						"run",
+						"--hosts", "3",
+						"--plan", planFile,
+						"--payload", filepath.Join(".", "mock", "noop-payload.sh"),
+						"--ca-file", filepath.Join(".", "test-data", "ssl-data", "ca.crt"),
+					},
+					expectedRequests: []proto.Message{
+						&Ydb_Auth.LoginRequest{
+							User:     mock.TestUser,
+							Password: mock.TestPassword,
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Cms.ListDatabasesRequest{},
+						&Ydb_Discovery.WhoAmIRequest{},
+						&Ydb_Maintenance.ListMaintenanceTasksRequest{
+							User: &mock.TestUser,
+						},
+						&Ydb_Maintenance.CreateMaintenanceTaskRequest{
+							TaskOptions: &Ydb_Maintenance.MaintenanceTaskOptions{
+								TaskUid:          "task-UUID-1",
+								Description:      "Rolling restart maintenance task",
+								AvailabilityMode: Ydb_Maintenance.AvailabilityMode_AVAILABILITY_MODE_STRONG,
+							},
+							ActionGroups: mock.MakeActionGroupsFromNodeIdsWithInflight(2, 1, 2),
+						},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-1",
+									ActionId: "action-UUID-1",
+								},
+							},
+						},
+						&Ydb_Maintenance.RefreshMaintenanceTaskRequest{
+							TaskUid: "task-UUID-1",
+						},
+						&Ydb_Maintenance.ListClusterNodesRequest{},
+						&Ydb_Maintenance.CompleteActionRequest{
+							ActionUids: []*Ydb_Maintenance.ActionUid{
+								{
+									TaskUid:  "task-UUID-1",
+									GroupId:  "group-UUID-2",
+									ActionId: "action-UUID-2",
+								},
+							},
+						},
+					},
+					expectedOutputRegexps: []string{
+						"Restarting 2 nodes with RunRestarter as planned",
+					},
+				},
+			},
+		},
+		),
 	)
 })
@@ -124,8 +124,10 @@ func RunTestCase(tc TestCase) {
 		for _, expectedOutputRegexp := range step.expectedOutputRegexps {
 			// This `if` means that `ydbops maintenance create` command has just
 			// finished executing. We will extract maintenance task id from it
-			// and pass it to the next invocations within this test.
-			if strings.Contains(expectedOutputRegexp, "Your task id is:") {
+			// (either from text or from --output json) and pass it to the next
+			// invocations within this test.
+			if strings.Contains(expectedOutputRegexp, "Your task id is:") ||
+				strings.Contains(expectedOutputRegexp, `"uid": "`) {
 				uuidOnlyRegexp := regexp.MustCompile(
 					fmt.Sprintf("(%s%s)",
 						cms.TaskUuidPrefix,
@@ -184,6 +186,7 @@ func RunTestCase(tc TestCase) {
 			Expect(cmp.Diff(expected, actual,
 				protocmp.Transform(),
 				blackmagic.ActionGroupSorter(),
+				blackmagic.ActionSorter(),
 				blackmagic.ActionUidSorter(),
 				blackmagic.UUIDComparer(expectedPlaceholders, actualPlaceholders),
 			)).To(BeEmpty())